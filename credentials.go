@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	graphmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
+)
+
+// Supported values for SecretInfo.CredentialType and --credential-types.
+const (
+	credentialTypePassword    = "password"
+	credentialTypeCertificate = "certificate"
+	credentialTypeFederated   = "federated"
+)
+
+// credentialTypeSet returns the set of credential types CheckSecrets
+// should inspect. An empty Config.CredentialTypes means all of them.
+func (c Config) credentialTypeSet() map[string]bool {
+	if len(c.CredentialTypes) == 0 {
+		return map[string]bool{
+			credentialTypePassword:    true,
+			credentialTypeCertificate: true,
+			credentialTypeFederated:   true,
+		}
+	}
+
+	set := make(map[string]bool, len(c.CredentialTypes))
+	for _, t := range c.CredentialTypes {
+		set[t] = true
+	}
+	return set
+}
+
+// passwordCredentialSecrets returns the app's password credentials that
+// are within thresholdDays of expiring.
+func passwordCredentialSecrets(app graphmodels.Applicationable, displayName, appID string, thresholdDays int) []SecretInfo {
+	var results []SecretInfo
+	for _, cred := range app.GetPasswordCredentials() {
+		endDateTime := cred.GetEndDateTime()
+		if endDateTime == nil {
+			continue
+		}
+
+		daysToExpiry := int(time.Until(endDateTime.UTC()).Hours() / 24)
+		if daysToExpiry > thresholdDays {
+			continue
+		}
+
+		keyID := cred.GetKeyId()
+		if keyID == nil {
+			continue
+		}
+
+		results = append(results, SecretInfo{
+			ApplicationName: displayName,
+			ApplicationID:   appID,
+			SecretID:        keyID.String(),
+			CredentialType:  credentialTypePassword,
+			ExpiryDate:      endDateTime.Format("2006-01-02"),
+			DaysToExpiry:    daysToExpiry,
+			Tags:            app.GetTags(),
+		})
+	}
+	return results
+}
+
+// certificateCredentialSecrets returns the app's key (certificate)
+// credentials that are within thresholdDays of expiring, along with the
+// thumbprint, subject, and issuer parsed from the certificate bytes.
+func certificateCredentialSecrets(app graphmodels.Applicationable, displayName, appID string, thresholdDays int) []SecretInfo {
+	var results []SecretInfo
+	for _, cred := range app.GetKeyCredentials() {
+		endDateTime := cred.GetEndDateTime()
+		if endDateTime == nil {
+			continue
+		}
+
+		daysToExpiry := int(time.Until(endDateTime.UTC()).Hours() / 24)
+		if daysToExpiry > thresholdDays {
+			continue
+		}
+
+		keyID := cred.GetKeyId()
+		if keyID == nil {
+			continue
+		}
+
+		secret := SecretInfo{
+			ApplicationName: displayName,
+			ApplicationID:   appID,
+			SecretID:        keyID.String(),
+			CredentialType:  credentialTypeCertificate,
+			ExpiryDate:      endDateTime.Format("2006-01-02"),
+			DaysToExpiry:    daysToExpiry,
+			Tags:            app.GetTags(),
+		}
+
+		if keyBytes := cred.GetKey(); keyBytes != nil {
+			if cert, err := x509.ParseCertificate(keyBytes); err == nil {
+				thumbprint := sha1.Sum(cert.Raw)
+				secret.Thumbprint = hex.EncodeToString(thumbprint[:])
+				secret.Subject = cert.Subject.String()
+				secret.Issuer = cert.Issuer.String()
+			}
+		}
+
+		results = append(results, secret)
+	}
+	return results
+}
+
+// federatedCredentialSecrets lists the app's federated identity
+// credentials. FICs do not expire, so every one found is reported rather
+// than filtered by the expiry threshold, to let owners audit the
+// issuer/subject/audience they trust.
+func (m *Monitor) federatedCredentialSecrets(ctx context.Context, app graphmodels.Applicationable, displayName, appID string) ([]SecretInfo, error) {
+	objectID := app.GetId()
+	if objectID == nil {
+		return nil, nil
+	}
+
+	fics, err := m.client.Applications().ByApplicationId(*objectID).FederatedIdentityCredentials().Get(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get federated identity credentials for app %s: %v", appID, err)
+	}
+
+	var results []SecretInfo
+	for _, fic := range fics.GetValue() {
+		id := fic.GetId()
+		if id == nil {
+			continue
+		}
+
+		secret := SecretInfo{
+			ApplicationName: displayName,
+			ApplicationID:   appID,
+			SecretID:        *id,
+			CredentialType:  credentialTypeFederated,
+			Tags:            app.GetTags(),
+		}
+
+		if issuer := fic.GetIssuer(); issuer != nil {
+			secret.Issuer = *issuer
+		}
+		if subject := fic.GetSubject(); subject != nil {
+			secret.Subject = *subject
+		}
+		secret.Audience = fic.GetAudiences()
+
+		results = append(results, secret)
+	}
+
+	return results, nil
+}
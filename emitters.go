@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Supported values for --emitter.
+const (
+	emitterStdout                = "stdout"
+	emitterPrometheusPushgateway = "prometheus-pushgateway"
+	emitterPrometheusTextfile    = "prometheus-textfile"
+	emitterAzureMonitor          = "azuremonitor"
+)
+
+// Emitter sends the results of a check to a destination: stdout, a
+// Prometheus Pushgateway, a node_exporter textfile, or Azure Monitor.
+type Emitter interface {
+	// Name identifies the emitter as used in --emitter.
+	Name() string
+	// Emit sends secrets to the emitter's destination.
+	Emit(ctx context.Context, secrets []SecretInfo, config Config) error
+}
+
+// buildEmitters resolves the --emitter names into Emitter implementations.
+func buildEmitters(config Config) ([]Emitter, error) {
+	names := config.Emitters
+	if len(names) == 0 {
+		names = []string{emitterStdout}
+	}
+
+	var emitters []Emitter
+	for _, name := range names {
+		switch name {
+		case emitterStdout:
+			emitters = append(emitters, StdoutEmitter{})
+		case emitterPrometheusPushgateway:
+			if config.PushgatewayURL == "" {
+				return nil, fmt.Errorf("--pushgateway-url is required for emitter=prometheus-pushgateway")
+			}
+			emitters = append(emitters, PrometheusPushgatewayEmitter{URL: config.PushgatewayURL})
+		case emitterPrometheusTextfile:
+			if config.TextfilePath == "" {
+				return nil, fmt.Errorf("--textfile-path is required for emitter=prometheus-textfile")
+			}
+			emitters = append(emitters, PrometheusTextfileEmitter{Path: config.TextfilePath})
+		case emitterAzureMonitor:
+			if config.AzureMonitorResourceID == "" || config.AzureMonitorRegion == "" {
+				return nil, fmt.Errorf("--azuremonitor-resource-id and --azuremonitor-region are required for emitter=azuremonitor")
+			}
+			cred, err := newCredential(config)
+			if err != nil {
+				return nil, fmt.Errorf("credential error: %v", err)
+			}
+			emitters = append(emitters, AzureMonitorEmitter{
+				ResourceID: strings.TrimLeft(config.AzureMonitorResourceID, "/"),
+				Region:     config.AzureMonitorRegion,
+				cred:       cred,
+			})
+		default:
+			return nil, fmt.Errorf("unknown emitter %q: must be one of stdout, prometheus-pushgateway, prometheus-textfile, azuremonitor", name)
+		}
+	}
+	return emitters, nil
+}
+
+// StdoutEmitter prints results to stdout in the format selected by
+// Config.Format.
+type StdoutEmitter struct{}
+
+// Name implements Emitter.
+func (StdoutEmitter) Name() string { return emitterStdout }
+
+// Emit implements Emitter.
+func (StdoutEmitter) Emit(ctx context.Context, secrets []SecretInfo, config Config) error {
+	outputter, err := buildOutputter(config)
+	if err != nil {
+		return err
+	}
+	return outputter.Render(os.Stdout, secrets, config)
+}
+
+// secretMetricVecs builds the gauge and counter vectors shared by the
+// Prometheus-based emitters, and populates them from secrets. Federated
+// credentials are skipped since they carry no expiry to report.
+func secretMetricVecs(secrets []SecretInfo) (*prometheus.GaugeVec, *prometheus.CounterVec) {
+	labels := []string{"app_id", "app_name", "secret_id", "credential_type"}
+
+	daysToExpiry := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "azuread_secret_days_to_expiry",
+		Help: "Days remaining until an Azure AD application credential expires.",
+	}, labels)
+
+	expired := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "azuread_secret_expired",
+		Help: "Count of Azure AD application credentials that have already expired.",
+	}, labels)
+
+	for _, secret := range secrets {
+		if secret.CredentialType == credentialTypeFederated {
+			continue
+		}
+
+		promLabels := prometheus.Labels{
+			"app_id":          secret.ApplicationID,
+			"app_name":        secret.ApplicationName,
+			"secret_id":       secret.SecretID,
+			"credential_type": secret.CredentialType,
+		}
+
+		daysToExpiry.With(promLabels).Set(float64(secret.DaysToExpiry))
+		if secret.DaysToExpiry < 0 {
+			expired.With(promLabels).Inc()
+		}
+	}
+
+	return daysToExpiry, expired
+}
+
+// PrometheusPushgatewayEmitter pushes the gauge/counter pair to a
+// Prometheus Pushgateway.
+type PrometheusPushgatewayEmitter struct {
+	URL string
+}
+
+// Name implements Emitter.
+func (PrometheusPushgatewayEmitter) Name() string { return emitterPrometheusPushgateway }
+
+// Emit implements Emitter.
+func (e PrometheusPushgatewayEmitter) Emit(ctx context.Context, secrets []SecretInfo, config Config) error {
+	daysToExpiry, expired := secretMetricVecs(secrets)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(daysToExpiry, expired)
+
+	pusher := push.New(e.URL, "azure_secret_monitor").Gatherer(registry)
+	if err := pusher.PushContext(ctx); err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %v", e.URL, err)
+	}
+	return nil
+}
+
+// PrometheusTextfileEmitter writes the gauge/counter pair to a file in the
+// Prometheus text exposition format, for node_exporter's textfile
+// collector to pick up.
+type PrometheusTextfileEmitter struct {
+	Path string
+}
+
+// Name implements Emitter.
+func (PrometheusTextfileEmitter) Name() string { return emitterPrometheusTextfile }
+
+// Emit implements Emitter.
+func (e PrometheusTextfileEmitter) Emit(ctx context.Context, secrets []SecretInfo, config Config) error {
+	daysToExpiry, expired := secretMetricVecs(secrets)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(daysToExpiry, expired)
+
+	families, err := registry.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %v", err)
+	}
+
+	var buf bytes.Buffer
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(&buf, family); err != nil {
+			return fmt.Errorf("failed to encode metrics: %v", err)
+		}
+	}
+
+	// Write to a temp file in the same directory and rename into place so
+	// node_exporter never reads a partially written file.
+	tmpPath := e.Path + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, e.Path); err != nil {
+		return fmt.Errorf("failed to move %s into place at %s: %v", tmpPath, e.Path, err)
+	}
+	return nil
+}
+
+// AzureMonitorEmitter publishes results as custom metrics to Azure
+// Monitor's metrics ingestion endpoint, using the same credential chain
+// configured for Graph.
+type AzureMonitorEmitter struct {
+	ResourceID string
+	Region     string
+
+	cred azcore.TokenCredential
+}
+
+// Name implements Emitter.
+func (AzureMonitorEmitter) Name() string { return emitterAzureMonitor }
+
+// azureMonitorMetric is the payload shape expected by the custom metrics
+// ingestion API.
+type azureMonitorMetric struct {
+	Time string                 `json:"time"`
+	Data azureMonitorMetricData `json:"data"`
+}
+
+type azureMonitorMetricData struct {
+	BaseData azureMonitorMetricBaseData `json:"baseData"`
+}
+
+type azureMonitorMetricBaseData struct {
+	Metric    string                     `json:"metric"`
+	Namespace string                     `json:"namespace"`
+	DimNames  []string                   `json:"dimNames"`
+	Series    []azureMonitorMetricSeries `json:"series"`
+}
+
+type azureMonitorMetricSeries struct {
+	DimValues []string `json:"dimValues"`
+	Min       float64  `json:"min"`
+	Max       float64  `json:"max"`
+	Sum       float64  `json:"sum"`
+	Count     int      `json:"count"`
+}
+
+// Emit implements Emitter. It aggregates DaysToExpiry (min/max/sum/count)
+// per credential type across the batch, since Azure Monitor custom
+// metrics are designed for pre-aggregated series rather than one point per
+// resource.
+func (e AzureMonitorEmitter) Emit(ctx context.Context, secrets []SecretInfo, config Config) error {
+	type aggregate struct {
+		min, max, sum float64
+		count         int
+	}
+	aggregates := map[string]*aggregate{}
+
+	for _, secret := range secrets {
+		if secret.CredentialType == credentialTypeFederated {
+			continue
+		}
+
+		agg, ok := aggregates[secret.CredentialType]
+		if !ok {
+			agg = &aggregate{min: float64(secret.DaysToExpiry), max: float64(secret.DaysToExpiry)}
+			aggregates[secret.CredentialType] = agg
+		}
+
+		value := float64(secret.DaysToExpiry)
+		if value < agg.min {
+			agg.min = value
+		}
+		if value > agg.max {
+			agg.max = value
+		}
+		agg.sum += value
+		agg.count++
+	}
+
+	if len(aggregates) == 0 {
+		return nil
+	}
+
+	var series []azureMonitorMetricSeries
+	for credentialType, agg := range aggregates {
+		series = append(series, azureMonitorMetricSeries{
+			DimValues: []string{credentialType},
+			Min:       agg.min,
+			Max:       agg.max,
+			Sum:       agg.sum,
+			Count:     agg.count,
+		})
+	}
+
+	metric := azureMonitorMetric{
+		Time: time.Now().UTC().Format(time.RFC3339),
+		Data: azureMonitorMetricData{
+			BaseData: azureMonitorMetricBaseData{
+				Metric:    "SecretDaysToExpiry",
+				Namespace: "AzureADSecretMonitor",
+				DimNames:  []string{"CredentialType"},
+				Series:    series,
+			},
+		},
+	}
+
+	body, err := json.Marshal(metric)
+	if err != nil {
+		return fmt.Errorf("failed to encode Azure Monitor payload: %v", err)
+	}
+
+	token, err := e.cred.GetToken(ctx, policy.TokenRequestOptions{
+		Scopes: []string{"https://monitor.azure.com/.default"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to acquire Azure Monitor token: %v", err)
+	}
+
+	url := fmt.Sprintf("https://%s.monitoring.azure.com/%s/metrics", e.Region, e.ResourceID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish Azure Monitor metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to publish Azure Monitor metrics: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	msgraphcore "github.com/microsoftgraph/msgraph-sdk-go-core"
+	"github.com/microsoftgraph/msgraph-sdk-go/applications"
+	graphmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
+)
+
+// applicationSelectFields limits listMonitoredApps' Graph response to the
+// fields CheckSecrets, RotateSecrets, and their callers actually use.
+var applicationSelectFields = []string{"id", "appId", "displayName", "tags", "passwordCredentials", "keyCredentials"}
+
+// FetchOptions narrows the applications listMonitoredApps returns, beyond
+// the MonitorTag check every run already applies.
+type FetchOptions struct {
+	// DisplayNamePrefix, if set, restricts applications to those whose
+	// display name starts with the given prefix (server-side $filter).
+	DisplayNamePrefix string
+	// OwnerID, if set, restricts applications to those owned by the given
+	// user object ID. Owners that are not users (e.g. service principals)
+	// are not looked up.
+	OwnerID string
+	// TagExpression, if set, restricts applications to those carrying the
+	// given tag, in addition to MonitorTag (server-side $filter, exact
+	// match; Graph's tags/any() does not support regular expressions).
+	TagExpression string
+}
+
+// odataEscape escapes a string for safe inclusion in a single-quoted OData
+// string literal.
+func odataEscape(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
+
+// filterClauses builds the $filter clauses implied by o, combined with
+// "and". OwnerID is applied separately, since Graph has no $filter for
+// application ownership.
+func (o FetchOptions) filterClauses() []string {
+	var clauses []string
+	if o.DisplayNamePrefix != "" {
+		clauses = append(clauses, fmt.Sprintf("startswith(displayName,'%s')", odataEscape(o.DisplayNamePrefix)))
+	}
+	if o.TagExpression != "" {
+		clauses = append(clauses, fmt.Sprintf("tags/any(t:t eq '%s')", odataEscape(o.TagExpression)))
+	}
+	return clauses
+}
+
+// ownedApplicationIDs returns the object IDs of applications owned by
+// m.fetchOptions.OwnerID, or nil if OwnerID is unset. Graph has no $filter
+// for application ownership, so this walks the owner's ownedObjects
+// instead of the applications list.
+func (m *Monitor) ownedApplicationIDs(ctx context.Context) (map[string]bool, error) {
+	ownerID := m.fetchOptions.OwnerID
+	if ownerID == "" {
+		return nil, nil
+	}
+
+	owned, err := m.client.Users().ByUserId(ownerID).OwnedObjects().Get(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get owned objects for %s: %v", ownerID, err)
+	}
+
+	ids := make(map[string]bool)
+	for _, obj := range owned.GetValue() {
+		app, ok := obj.(graphmodels.Applicationable)
+		if !ok {
+			continue
+		}
+		if id := app.GetId(); id != nil {
+			ids[*id] = true
+		}
+	}
+	return ids, nil
+}
+
+// listMonitoredApps returns the applications tagged with config.MonitorTag,
+// narrowed by any filters set in m.fetchOptions, paginating through the
+// full result set via PageIterator.
+func (m *Monitor) listMonitoredApps(ctx context.Context) ([]graphmodels.Applicationable, error) {
+	ownedIDs, err := m.ownedApplicationIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	requestConfig := &applications.ApplicationsRequestBuilderGetRequestConfiguration{
+		QueryParameters: &applications.ApplicationsRequestBuilderGetQueryParameters{
+			Select: applicationSelectFields,
+		},
+	}
+	if clauses := m.fetchOptions.filterClauses(); len(clauses) > 0 {
+		filter := strings.Join(clauses, " and ")
+		requestConfig.QueryParameters.Filter = &filter
+	}
+
+	page, err := m.client.Applications().Get(ctx, requestConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applications: %v", err)
+	}
+
+	iterator, err := msgraphcore.NewPageIterator[graphmodels.Applicationable](
+		page,
+		m.client.GetAdapter(),
+		graphmodels.CreateApplicationCollectionResponseFromDiscriminatorValue,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create page iterator: %v", err)
+	}
+
+	var monitored []graphmodels.Applicationable
+	err = iterator.Iterate(ctx, func(app graphmodels.Applicationable) bool {
+		if !contains(app.GetTags(), m.config.MonitorTag) {
+			return true
+		}
+		if ownedIDs != nil {
+			id := app.GetId()
+			if id == nil || !ownedIDs[*id] {
+				return true
+			}
+		}
+		monitored = append(monitored, app)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate applications: %v", err)
+	}
+
+	return monitored, nil
+}
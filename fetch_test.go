@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestODataEscape(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"O'Brien", "O''Brien"},
+		{"'''", "''''''"},
+	}
+
+	for _, tt := range tests {
+		if got := odataEscape(tt.in); got != tt.want {
+			t.Errorf("odataEscape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFilterClauses(t *testing.T) {
+	tests := []struct {
+		name string
+		opts FetchOptions
+		want []string
+	}{
+		{
+			name: "no filters",
+			opts: FetchOptions{},
+			want: nil,
+		},
+		{
+			name: "display name prefix",
+			opts: FetchOptions{DisplayNamePrefix: "billing-"},
+			want: []string{"startswith(displayName,'billing-')"},
+		},
+		{
+			name: "tag expression escapes quotes",
+			opts: FetchOptions{TagExpression: "owner:o'brien"},
+			want: []string{"tags/any(t:t eq 'owner:o''brien')"},
+		},
+		{
+			name: "both filters combine in order",
+			opts: FetchOptions{DisplayNamePrefix: "billing-", TagExpression: "env:prod"},
+			want: []string{
+				"startswith(displayName,'billing-')",
+				"tags/any(t:t eq 'env:prod')",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.filterClauses(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterClauses() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
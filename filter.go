@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// applyResultFilter runs expression (a JMESPath query) against secrets and
+// returns the filtered result, for the --filter-result flag. An empty
+// expression returns secrets unchanged.
+func applyResultFilter(secrets []SecretInfo, expression string) ([]SecretInfo, error) {
+	if expression == "" {
+		return secrets, nil
+	}
+
+	// jmespath.Search expects data shaped like the output of
+	// json.Unmarshal (maps and slices), so round-trip through JSON rather
+	// than evaluating the expression against SecretInfo directly.
+	raw, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode secrets for filtering: %v", err)
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode secrets for filtering: %v", err)
+	}
+
+	result, err := jmespath.Search(expression, data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter-result expression %q: %v", expression, err)
+	}
+
+	filtered, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode filter-result output: %v", err)
+	}
+
+	var filteredSecrets []SecretInfo
+	if err := json.Unmarshal(filtered, &filteredSecrets); err != nil {
+		return nil, fmt.Errorf("filter-result expression %q did not produce a list of secrets: %v", expression, err)
+	}
+
+	return filteredSecrets, nil
+}
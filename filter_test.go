@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestApplyResultFilter(t *testing.T) {
+	secrets := []SecretInfo{
+		{ApplicationName: "app-a", CredentialType: credentialTypePassword, DaysToExpiry: 5},
+		{ApplicationName: "app-b", CredentialType: credentialTypeCertificate, DaysToExpiry: 40},
+	}
+
+	t.Run("empty expression returns secrets unchanged", func(t *testing.T) {
+		got, err := applyResultFilter(secrets, "")
+		if err != nil {
+			t.Fatalf("applyResultFilter returned error: %v", err)
+		}
+		if len(got) != len(secrets) {
+			t.Fatalf("got %d secrets, want %d", len(got), len(secrets))
+		}
+	})
+
+	t.Run("filters by field", func(t *testing.T) {
+		got, err := applyResultFilter(secrets, "[?days_to_expiry < `10`]")
+		if err != nil {
+			t.Fatalf("applyResultFilter returned error: %v", err)
+		}
+		if len(got) != 1 || got[0].ApplicationName != "app-a" {
+			t.Fatalf("got %+v, want only app-a", got)
+		}
+	})
+
+	t.Run("invalid expression errors", func(t *testing.T) {
+		if _, err := applyResultFilter(secrets, "[?"); err == nil {
+			t.Fatal("applyResultFilter returned nil error for an invalid expression")
+		}
+	})
+
+	t.Run("expression producing a non-secret shape errors", func(t *testing.T) {
+		if _, err := applyResultFilter(secrets, "length(@)"); err == nil {
+			t.Fatal("applyResultFilter returned nil error for an expression that doesn't produce a list of secrets")
+		}
+	})
+}
@@ -7,36 +7,124 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	msgraph "github.com/microsoftgraph/msgraph-sdk-go"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// Supported values for Config.AuthMethod.
+const (
+	authMethodSPN      = "spn"
+	authMethodMSI      = "msi"
+	authMethodAzureCLI = "azcli"
+	authMethodWorkload = "workload"
+	authMethodDevice   = "device"
+	authMethodDefault  = "default"
+)
+
 // Config holds all application configuration parameters used to configure
 // the Azure AD secret monitoring tool.
 type Config struct {
 	// ClientID is the Azure AD application client ID
 	ClientID string `mapstructure:"client_id"`
-	// ClientSecret is the Azure AD application client secret
+	// ClientSecret is the Azure AD application client secret. Only required
+	// when AuthMethod is "spn".
 	ClientSecret string `mapstructure:"client_secret"`
 	// TenantID is the Azure AD tenant ID
 	TenantID string `mapstructure:"tenant_id"`
+	// AuthMethod selects which azidentity credential chain to authenticate
+	// with: spn, msi, azcli, workload, device, or default.
+	AuthMethod string `mapstructure:"auth_method"`
+	// ManagedIdentityClientID optionally selects a user-assigned managed
+	// identity when AuthMethod is "msi".
+	ManagedIdentityClientID string `mapstructure:"managed_identity_client_id"`
 	// MonitorTag is the tag used to filter which applications to monitor
 	MonitorTag string `mapstructure:"monitor_tag"`
 	// ExpiryThresholdDays is the number of days before expiration to check secrets
 	ExpiryThresholdDays int `mapstructure:"expiry_threshold_days"`
 	// Format specifies the output format (text or json)
 	Format string `mapstructure:"format"`
+	// RotateNewSecretLifetimeDays is the lifetime, in days, of secrets
+	// minted by the rotate subcommand.
+	RotateNewSecretLifetimeDays int `mapstructure:"-"`
+	// RotateNewSecretDisplayName names secrets minted by the rotate
+	// subcommand.
+	RotateNewSecretDisplayName string `mapstructure:"-"`
+	// RotateRetireOldAfterDays is the grace period, in days, before the
+	// rotate subcommand removes the credential it replaced.
+	RotateRetireOldAfterDays int `mapstructure:"-"`
+	// CredentialTypes restricts CheckSecrets to the given credential types
+	// (password, certificate, federated). Empty means all of them.
+	CredentialTypes []string `mapstructure:"-"`
+	// FilterDisplayName restricts listMonitoredApps to applications whose
+	// display name starts with the given prefix.
+	FilterDisplayName string `mapstructure:"-"`
+	// FilterOwnerID restricts listMonitoredApps to applications owned by
+	// the given user object ID.
+	FilterOwnerID string `mapstructure:"-"`
+	// FilterTagExpression restricts listMonitoredApps to applications
+	// carrying the given tag, in addition to MonitorTag.
+	FilterTagExpression string `mapstructure:"-"`
+	// FilterResult is a JMESPath expression used to post-filter the
+	// collected secrets before they are rendered or emitted. Empty means
+	// no post-filtering.
+	FilterResult string `mapstructure:"-"`
+	// TemplateFile is the path to a Go template file used to render
+	// results when Format is "template".
+	TemplateFile string `mapstructure:"-"`
+	// Emitters lists the destinations results are sent to (stdout,
+	// prometheus-pushgateway, prometheus-textfile, azuremonitor).
+	Emitters []string `mapstructure:"-"`
+	// PushgatewayURL is the Prometheus Pushgateway to push metrics to.
+	// Required when Emitters includes "prometheus-pushgateway".
+	PushgatewayURL string `mapstructure:"-"`
+	// TextfilePath is where metrics are written for node_exporter's
+	// textfile collector. Required when Emitters includes
+	// "prometheus-textfile".
+	TextfilePath string `mapstructure:"-"`
+	// AzureMonitorResourceID is the resource ID custom metrics are
+	// published against. Required when Emitters includes "azuremonitor".
+	AzureMonitorResourceID string `mapstructure:"-"`
+	// AzureMonitorRegion is the Azure region of AzureMonitorResourceID,
+	// e.g. "eastus". Required when Emitters includes "azuremonitor".
+	AzureMonitorRegion string `mapstructure:"-"`
+	// Notifiers declares named alert destinations, set via the config file.
+	Notifiers []NotifierConfig `mapstructure:"notifiers"`
+	// NotificationRoutes maps applications to the notifiers that should
+	// alert on them, set via the config file.
+	NotificationRoutes []NotificationRoute `mapstructure:"notification_routes"`
+	// NotifyTypes are the ad-hoc notifier types selected via --notify.
+	NotifyTypes []string `mapstructure:"-"`
+	// NotifySeverityThresholds is the raw --notify-severity-thresholds value.
+	NotifySeverityThresholds string `mapstructure:"-"`
+	// NotifyDryRun prints rendered notification payloads instead of sending them.
+	NotifyDryRun bool `mapstructure:"-"`
+	// NotifyTeamsWebhookURL/NotifySlackWebhookURL/NotifyWebhookURL and the
+	// NotifySMTP* fields configure the ad-hoc notifiers selected via
+	// --notify; they have no effect on named notifiers.
+	NotifyTeamsWebhookURL string   `mapstructure:"-"`
+	NotifySlackWebhookURL string   `mapstructure:"-"`
+	NotifyWebhookURL      string   `mapstructure:"-"`
+	NotifySMTPHost        string   `mapstructure:"-"`
+	NotifySMTPPort        int      `mapstructure:"-"`
+	NotifySMTPUsername    string   `mapstructure:"-"`
+	NotifySMTPPassword    string   `mapstructure:"-"`
+	NotifySMTPFrom        string   `mapstructure:"-"`
+	NotifySMTPTo          []string `mapstructure:"-"`
 }
 
-// SecretInfo represents detailed information about an expiring secret
-// including its associated application and expiration details.
+// SecretInfo represents detailed information about a credential nearing
+// expiration, or (for federated credentials, which never expire) a
+// credential surfaced for audit purposes.
 type SecretInfo struct {
 	// ApplicationName is the display name of the Azure AD application
 	ApplicationName string `json:"application_name"`
@@ -44,10 +132,29 @@ type SecretInfo struct {
 	ApplicationID string `json:"application_id"`
 	// SecretID is the unique identifier of the secret
 	SecretID string `json:"secret_id"`
-	// ExpiryDate is the date when the secret will expire (format: YYYY-MM-DD)
-	ExpiryDate string `json:"expiry_date"`
-	// DaysToExpiry is the number of days until the secret expires
-	DaysToExpiry int `json:"days_to_expiry"`
+	// CredentialType is one of "password", "certificate", or "federated"
+	CredentialType string `json:"credential_type"`
+	// ExpiryDate is the date when the secret will expire (format:
+	// YYYY-MM-DD). Empty for federated credentials, which do not expire.
+	ExpiryDate string `json:"expiry_date,omitempty"`
+	// DaysToExpiry is the number of days until the secret expires. Not
+	// meaningful for federated credentials.
+	DaysToExpiry int `json:"days_to_expiry,omitempty"`
+	// Thumbprint is the SHA-1 thumbprint of the certificate. Only set for
+	// CredentialType "certificate".
+	Thumbprint string `json:"thumbprint,omitempty"`
+	// Subject is the certificate subject, or the federated credential's
+	// subject claim. Set for "certificate" and "federated".
+	Subject string `json:"subject,omitempty"`
+	// Issuer is the certificate issuer, or the federated credential's
+	// issuer URL. Set for "certificate" and "federated".
+	Issuer string `json:"issuer,omitempty"`
+	// Audience lists the federated credential's accepted audiences. Only
+	// set for CredentialType "federated".
+	Audience []string `json:"audience,omitempty"`
+	// Tags are the application's Azure AD tags, used for notifier routing
+	// (e.g. matching an "owner:" tag) and filtering.
+	Tags []string `json:"tags,omitempty"`
 }
 
 // ExecutionInfo contains metadata about the current execution
@@ -80,20 +187,16 @@ type OutputResult struct {
 // It maintains a connection to the Microsoft Graph API and implements
 // the secret checking logic.
 type Monitor struct {
-	client *msgraph.GraphServiceClient
-	config Config
+	client       *msgraph.GraphServiceClient
+	config       Config
+	fetchOptions FetchOptions
 }
 
 // NewMonitor creates a new Monitor instance with the provided configuration.
 // It establishes the necessary Azure AD authentication and creates a Microsoft
 // Graph API client.
 func NewMonitor(config Config) (*Monitor, error) {
-	cred, err := azidentity.NewClientSecretCredential(
-		config.TenantID,
-		config.ClientID,
-		config.ClientSecret,
-		nil,
-	)
+	cred, err := newCredential(config)
 	if err != nil {
 		return nil, fmt.Errorf("credential error: %v", err)
 	}
@@ -106,55 +209,90 @@ func NewMonitor(config Config) (*Monitor, error) {
 	return &Monitor{
 		client: client,
 		config: config,
+		fetchOptions: FetchOptions{
+			DisplayNamePrefix: config.FilterDisplayName,
+			OwnerID:           config.FilterOwnerID,
+			TagExpression:     config.FilterTagExpression,
+		},
 	}, nil
 }
 
-// CheckSecrets queries the Microsoft Graph API to retrieve all application secrets
-// and checks for those approaching expiration based on the configured notification
-// threshold (ExpiryThresholdDays).
+// newCredential builds the azidentity TokenCredential selected by
+// config.AuthMethod. "spn" (the default) preserves the original
+// client-secret behavior; the other methods let the tool run without a
+// stored secret, e.g. as an AKS workload identity or an MSI-enabled
+// Azure Function.
+func newCredential(config Config) (azcore.TokenCredential, error) {
+	switch config.AuthMethod {
+	case "", authMethodSPN:
+		return azidentity.NewClientSecretCredential(
+			config.TenantID,
+			config.ClientID,
+			config.ClientSecret,
+			nil,
+		)
+	case authMethodMSI:
+		opts := &azidentity.ManagedIdentityCredentialOptions{}
+		if config.ManagedIdentityClientID != "" {
+			opts.ID = azidentity.ClientID(config.ManagedIdentityClientID)
+		}
+		return azidentity.NewManagedIdentityCredential(opts)
+	case authMethodAzureCLI:
+		return azidentity.NewAzureCLICredential(nil)
+	case authMethodWorkload:
+		return azidentity.NewWorkloadIdentityCredential(nil)
+	case authMethodDevice:
+		return azidentity.NewDeviceCodeCredential(&azidentity.DeviceCodeCredentialOptions{
+			TenantID: config.TenantID,
+			ClientID: config.ClientID,
+			UserPrompt: func(ctx context.Context, message azidentity.DeviceCodeMessage) error {
+				fmt.Println(message.Message)
+				return nil
+			},
+		})
+	case authMethodDefault:
+		return azidentity.NewDefaultAzureCredential(nil)
+	default:
+		return nil, fmt.Errorf("unknown auth-method %q: must be one of spn, msi, azcli, workload, device, default", config.AuthMethod)
+	}
+}
+
+// CheckSecrets queries the Microsoft Graph API to retrieve all application
+// credentials and checks for those approaching expiration based on the
+// configured notification threshold (ExpiryThresholdDays). Which
+// credential types are inspected is controlled by Config.CredentialTypes.
 func (m *Monitor) CheckSecrets(ctx context.Context) ([]SecretInfo, error) {
-	apps, err := m.client.Applications().Get(ctx, nil)
+	apps, err := m.listMonitoredApps(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get applications: %v", err)
+		return nil, err
 	}
 
+	types := m.config.credentialTypeSet()
+
 	var results []SecretInfo
-	for _, app := range apps.GetValue() {
-		if !contains(app.GetTags(), m.config.MonitorTag) {
+	for _, app := range apps {
+		displayName := app.GetDisplayName()
+		appID := app.GetAppId()
+		if displayName == nil || appID == nil {
 			continue
 		}
 
-		for _, cred := range app.GetPasswordCredentials() {
-			endDateTime := cred.GetEndDateTime()
-			if endDateTime == nil {
+		if types[credentialTypePassword] {
+			results = append(results, passwordCredentialSecrets(app, *displayName, *appID, m.config.ExpiryThresholdDays)...)
+		}
+		if types[credentialTypeCertificate] {
+			results = append(results, certificateCredentialSecrets(app, *displayName, *appID, m.config.ExpiryThresholdDays)...)
+		}
+		if types[credentialTypeFederated] {
+			fics, err := m.federatedCredentialSecrets(ctx, app, *displayName, *appID)
+			if err != nil {
+				// A single app's federated credentials call (permissions,
+				// throttling, an app type that doesn't support FICs) should
+				// not abort the scan for every other app; log and move on.
+				log.Printf("failed to list federated credentials for %s (%s): %v", *displayName, *appID, err)
 				continue
 			}
-
-			daysToExpiry := int(time.Until(endDateTime.UTC()).Hours() / 24)
-			if daysToExpiry <= m.config.ExpiryThresholdDays {
-				keyID := cred.GetKeyId()
-				if keyID == nil {
-					continue
-				}
-
-				displayName := app.GetDisplayName()
-				if displayName == nil {
-					continue
-				}
-
-				appID := app.GetAppId()
-				if appID == nil {
-					continue
-				}
-
-				results = append(results, SecretInfo{
-					ApplicationName: *displayName,
-					ApplicationID:   *appID,
-					SecretID:        keyID.String(),
-					ExpiryDate:      endDateTime.Format("2006-01-02"),
-					DaysToExpiry:    daysToExpiry,
-				})
-			}
+			results = append(results, fics...)
 		}
 	}
 
@@ -183,8 +321,8 @@ func contains(slice []string, pattern string) bool {
 	return false
 }
 
-// printJSON outputs the secret information in JSON format to stdout.
-func printJSON(secrets []SecretInfo, config Config) error {
+// printJSON writes the secret information in JSON format to w.
+func printJSON(w io.Writer, secrets []SecretInfo, config Config) error {
 	if secrets == nil {
 		secrets = []SecretInfo{} // Convert nil to empty slice
 	}
@@ -201,33 +339,45 @@ func printJSON(secrets []SecretInfo, config Config) error {
 		},
 	}
 
-	encoder := json.NewEncoder(os.Stdout)
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(result)
 }
 
-// printText outputs the secret information in a human-readable format to stdout.
-func printText(secrets []SecretInfo, config Config) {
-	fmt.Printf("Azure Secret Monitor Report\n")
-	fmt.Printf("Generated at: %s\n", time.Now().UTC().Format(time.RFC3339))
-	fmt.Printf("Configuration:\n")
-	fmt.Printf("  - Expiry Threshold: %d days\n", config.ExpiryThresholdDays)
-	fmt.Printf("  - Monitor Tag: %s\n", config.MonitorTag)
-	fmt.Printf("\n")
+// printText writes the secret information in a human-readable format to w.
+func printText(w io.Writer, secrets []SecretInfo, config Config) {
+	fmt.Fprintf(w, "Azure Secret Monitor Report\n")
+	fmt.Fprintf(w, "Generated at: %s\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(w, "Configuration:\n")
+	fmt.Fprintf(w, "  - Expiry Threshold: %d days\n", config.ExpiryThresholdDays)
+	fmt.Fprintf(w, "  - Monitor Tag: %s\n", config.MonitorTag)
+	fmt.Fprintf(w, "\n")
 
 	if secrets == nil || len(secrets) == 0 {
-		fmt.Println("No expiring secrets found.")
+		fmt.Fprintln(w, "No expiring secrets found.")
 		return
 	}
 
-	fmt.Printf("Found %d expiring secrets:\n\n", len(secrets))
+	fmt.Fprintf(w, "Found %d expiring secrets:\n\n", len(secrets))
 	for _, secret := range secrets {
-		fmt.Printf("Application: %s\n", secret.ApplicationName)
-		fmt.Printf("App ID: %s\n", secret.ApplicationID)
-		fmt.Printf("Secret ID: %s\n", secret.SecretID)
-		fmt.Printf("Expiry Date: %s\n", secret.ExpiryDate)
-		fmt.Printf("Days Until Expiry: %d\n", secret.DaysToExpiry)
-		fmt.Println(strings.Repeat("-", 50))
+		fmt.Fprintf(w, "Application: %s\n", secret.ApplicationName)
+		fmt.Fprintf(w, "App ID: %s\n", secret.ApplicationID)
+		fmt.Fprintf(w, "Secret ID: %s\n", secret.SecretID)
+		fmt.Fprintf(w, "Credential Type: %s\n", secret.CredentialType)
+		if secret.CredentialType == credentialTypeFederated {
+			fmt.Fprintf(w, "Issuer: %s\n", secret.Issuer)
+			fmt.Fprintf(w, "Subject: %s\n", secret.Subject)
+			fmt.Fprintf(w, "Audience: %s\n", strings.Join(secret.Audience, ", "))
+		} else {
+			fmt.Fprintf(w, "Expiry Date: %s\n", secret.ExpiryDate)
+			fmt.Fprintf(w, "Days Until Expiry: %d\n", secret.DaysToExpiry)
+			if secret.CredentialType == credentialTypeCertificate {
+				fmt.Fprintf(w, "Thumbprint: %s\n", secret.Thumbprint)
+				fmt.Fprintf(w, "Subject: %s\n", secret.Subject)
+				fmt.Fprintf(w, "Issuer: %s\n", secret.Issuer)
+			}
+		}
+		fmt.Fprintln(w, strings.Repeat("-", 50))
 	}
 }
 
@@ -249,6 +399,7 @@ func initConfig(cfgFile string) error {
 	viper.SetDefault("format", "text")
 	viper.SetDefault("monitor_tag", "MonitorSecrets")
 	viper.SetDefault("expiry_threshold_days", 30)
+	viper.SetDefault("auth_method", authMethodSPN)
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -271,7 +422,10 @@ func main() {
 		Short:   "Monitor Azure AD application secrets for expiration",
 		Long:    `A tool to monitor Azure AD application secrets and identify those approaching expiration.`,
 		Version: "1.0.0",
-		PreRunE: func(cmd *cobra.Command, args []string) error {
+		// PersistentPreRunE loads and validates the configuration shared by
+		// every subcommand (authentication, tenant, monitor tag). It runs
+		// for both the default check behavior and the `rotate` subcommand.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			if err := initConfig(cfgFile); err != nil {
 				return err
 			}
@@ -279,12 +433,13 @@ func main() {
 			// Bind all flags to viper using the correct mapping
 			flags := cmd.Flags()
 			mappings := map[string]string{
-				"client-id":             "client_id",
-				"client-secret":         "client_secret",
-				"tenant-id":             "tenant_id",
-				"monitor-tag":           "monitor_tag",
-				"expiry-threshold-days": "expiry_threshold_days",
-				"format":                "format",
+				"client-id":                  "client_id",
+				"client-secret":              "client_secret",
+				"tenant-id":                  "tenant_id",
+				"monitor-tag":                "monitor_tag",
+				"expiry-threshold-days":      "expiry_threshold_days",
+				"auth-method":                "auth_method",
+				"managed-identity-client-id": "managed_identity_client_id",
 			}
 
 			for flagName, configKey := range mappings {
@@ -297,21 +452,93 @@ func main() {
 				return fmt.Errorf("error unmarshaling config: %w", err)
 			}
 
+			config.FilterDisplayName, _ = flags.GetString("filter-display-name")
+			config.FilterOwnerID, _ = flags.GetString("filter-owner-id")
+			config.FilterTagExpression, _ = flags.GetString("filter-tag-expression")
+
+			// Validate auth method
+			switch config.AuthMethod {
+			case authMethodSPN, authMethodMSI, authMethodAzureCLI, authMethodWorkload, authMethodDevice, authMethodDefault:
+			default:
+				return fmt.Errorf("invalid auth-method '%s': must be one of spn, msi, azcli, workload, device, default", config.AuthMethod)
+			}
+
 			// Validate required fields
-			if config.ClientID == "" {
-				return fmt.Errorf("client ID is required (use --client-id flag, AZURE_CLIENT_ID env var, or config file)")
+			if config.AuthMethod == authMethodSPN || config.AuthMethod == authMethodDevice {
+				if config.ClientID == "" {
+					return fmt.Errorf("client ID is required for auth-method '%s' (use --client-id flag, AZURE_CLIENT_ID env var, or config file)", config.AuthMethod)
+				}
 			}
-			if config.ClientSecret == "" {
-				return fmt.Errorf("client secret is required (use --client-secret flag, AZURE_CLIENT_SECRET env var, or config file)")
+			if config.AuthMethod == authMethodSPN && config.ClientSecret == "" {
+				return fmt.Errorf("client secret is required for auth-method 'spn' (use --client-secret flag, AZURE_CLIENT_SECRET env var, or config file)")
 			}
 			if config.TenantID == "" {
 				return fmt.Errorf("tenant ID is required (use --tenant-id flag, AZURE_TENANT_ID env var, or config file)")
 			}
 
+			return nil
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("format", cmd.Flags().Lookup("format")); err != nil {
+				return fmt.Errorf("error binding flag 'format': %w", err)
+			}
+			if err := viper.Unmarshal(&config); err != nil {
+				return fmt.Errorf("error unmarshaling config: %w", err)
+			}
+
 			// Validate format
-			if config.Format != "text" && config.Format != "json" {
-				return fmt.Errorf("invalid format '%s': must be 'text' or 'json'", config.Format)
+			switch config.Format {
+			case formatText, formatJSON, formatCSV, formatYAML, formatTable, formatTemplate:
+			default:
+				return fmt.Errorf("invalid format '%s': must be one of text, json, csv, yaml, table, template", config.Format)
+			}
+
+			config.TemplateFile, _ = cmd.Flags().GetString("template-file")
+			if config.Format == formatTemplate && config.TemplateFile == "" {
+				return fmt.Errorf("--template-file is required for format=template")
+			}
+
+			config.FilterResult, _ = cmd.Flags().GetString("filter-result")
+
+			credentialTypes, err := cmd.Flags().GetStringSlice("credential-types")
+			if err != nil {
+				return fmt.Errorf("error reading flag 'credential-types': %w", err)
+			}
+			for _, t := range credentialTypes {
+				switch t {
+				case credentialTypePassword, credentialTypeCertificate, credentialTypeFederated:
+				default:
+					return fmt.Errorf("invalid credential-types value '%s': must be one of password, certificate, federated", t)
+				}
+			}
+			config.CredentialTypes = credentialTypes
+
+			emitterNames, err := cmd.Flags().GetStringSlice("emitter")
+			if err != nil {
+				return fmt.Errorf("error reading flag 'emitter': %w", err)
 			}
+			config.Emitters = emitterNames
+			config.PushgatewayURL, _ = cmd.Flags().GetString("pushgateway-url")
+			config.TextfilePath, _ = cmd.Flags().GetString("textfile-path")
+			config.AzureMonitorResourceID, _ = cmd.Flags().GetString("azuremonitor-resource-id")
+			config.AzureMonitorRegion, _ = cmd.Flags().GetString("azuremonitor-region")
+
+			notifyTypes, err := cmd.Flags().GetStringSlice("notify")
+			if err != nil {
+				return fmt.Errorf("error reading flag 'notify': %w", err)
+			}
+			config.NotifyTypes = notifyTypes
+			config.NotifySeverityThresholds, _ = cmd.Flags().GetString("notify-severity-thresholds")
+			config.NotifyDryRun, _ = cmd.Flags().GetBool("dry-run-notify")
+			config.NotifyTeamsWebhookURL, _ = cmd.Flags().GetString("notify-teams-webhook-url")
+			config.NotifySlackWebhookURL, _ = cmd.Flags().GetString("notify-slack-webhook-url")
+			config.NotifyWebhookURL, _ = cmd.Flags().GetString("notify-webhook-url")
+			config.NotifySMTPHost, _ = cmd.Flags().GetString("notify-smtp-host")
+			config.NotifySMTPPort, _ = cmd.Flags().GetInt("notify-smtp-port")
+			config.NotifySMTPUsername, _ = cmd.Flags().GetString("notify-smtp-username")
+			config.NotifySMTPPassword, _ = cmd.Flags().GetString("notify-smtp-password")
+			config.NotifySMTPFrom, _ = cmd.Flags().GetString("notify-smtp-from")
+			config.NotifySMTPTo, _ = cmd.Flags().GetStringSlice("notify-smtp-to")
 
 			return nil
 		},
@@ -331,27 +558,72 @@ func main() {
 				secrets = []SecretInfo{} // Convert nil to empty slice
 			}
 
-			if config.Format == "json" {
-				if err := printJSON(secrets, config); err != nil {
-					return fmt.Errorf("failed to print JSON: %v", err)
+			secrets, err = applyResultFilter(secrets, config.FilterResult)
+			if err != nil {
+				return err
+			}
+
+			emitters, err := buildEmitters(config)
+			if err != nil {
+				return err
+			}
+
+			for _, emitter := range emitters {
+				if err := emitter.Emit(ctx, secrets, config); err != nil {
+					return fmt.Errorf("emitter %q failed: %v", emitter.Name(), err)
+				}
+			}
+
+			if len(config.NotifyTypes) > 0 || len(config.Notifiers) > 0 {
+				if err := sendNotifications(ctx, secrets, config); err != nil {
+					return fmt.Errorf("notification failed: %v", err)
 				}
-			} else {
-				printText(secrets, config)
 			}
 
 			return nil
 		},
 	}
 
-	// Define flags with names matching the config mapping
+	// Define flags shared with subcommands (auth, tenant, monitor tag) as
+	// persistent flags; flags specific to the default check behavior stay
+	// local to rootCmd.
+	persistentFlags := rootCmd.PersistentFlags()
+	persistentFlags.StringVar(&cfgFile, "config", "", "config file (default is ./config.yaml)")
+	persistentFlags.String("client-id", "", "Azure AD client ID")
+	persistentFlags.String("client-secret", "", "Azure AD client secret (required for auth-method=spn)")
+	persistentFlags.String("tenant-id", "", "Azure AD tenant ID")
+	persistentFlags.String("monitor-tag", "MonitorSecrets", "Tag to monitor")
+	persistentFlags.Int("expiry-threshold-days", 30, "Number of days before expiration to check secrets")
+	persistentFlags.String("auth-method", "spn", "Azure AD authentication method (spn/msi/azcli/workload/device/default)")
+	persistentFlags.String("managed-identity-client-id", "", "Client ID of a user-assigned managed identity (auth-method=msi only)")
+	persistentFlags.String("filter-display-name", "", "Only fetch applications whose display name starts with this prefix")
+	persistentFlags.String("filter-owner-id", "", "Only fetch applications owned by this user object ID")
+	persistentFlags.String("filter-tag-expression", "", "Only fetch applications carrying this tag, in addition to --monitor-tag")
+
 	flags := rootCmd.Flags()
-	flags.StringVar(&cfgFile, "config", "", "config file (default is ./config.yaml)")
-	flags.String("client-id", "", "Azure AD client ID")
-	flags.String("client-secret", "", "Azure AD client secret")
-	flags.String("tenant-id", "", "Azure AD tenant ID")
-	flags.String("monitor-tag", "MonitorSecrets", "Tag to monitor")
-	flags.Int("expiry-threshold-days", 30, "Number of days before expiration to check secrets")
-	flags.String("format", "text", "Output format (text/json)")
+	flags.String("format", "text", "Output format (text/json/csv/yaml/table/template)")
+	flags.String("template-file", "", "Path to a Go template file (required for format=template)")
+	flags.String("filter-result", "", "JMESPath expression to post-filter the collected results")
+	flags.StringSlice("credential-types", []string{credentialTypePassword, credentialTypeCertificate, credentialTypeFederated}, "Credential types to check (password/certificate/federated), may be repeated")
+	flags.StringSlice("emitter", []string{emitterStdout}, "Where to send results (stdout/prometheus-pushgateway/prometheus-textfile/azuremonitor), may be repeated")
+	flags.String("pushgateway-url", "", "Pushgateway URL (required for emitter=prometheus-pushgateway)")
+	flags.String("textfile-path", "", "Path to write node_exporter textfile metrics to (required for emitter=prometheus-textfile)")
+	flags.String("azuremonitor-resource-id", "", "Azure resource ID to publish custom metrics against, e.g. subscriptions/.../resourceGroups/... (required for emitter=azuremonitor; a leading slash is ignored)")
+	flags.String("azuremonitor-region", "", "Azure region of the target resource, e.g. eastus (required for emitter=azuremonitor)")
+	flags.StringSlice("notify", nil, "Ad-hoc notifier type(s) to alert (teams/slack/smtp/webhook), may be repeated; named notifiers come from the config file")
+	flags.String("notify-severity-thresholds", "", "Severity bucket thresholds in days, e.g. warning=30,critical=7,expired=0")
+	flags.Bool("dry-run-notify", false, "Print rendered notification payloads instead of sending them")
+	flags.String("notify-teams-webhook-url", "", "Teams Incoming Webhook URL (ad-hoc --notify=teams)")
+	flags.String("notify-slack-webhook-url", "", "Slack Incoming Webhook URL (ad-hoc --notify=slack)")
+	flags.String("notify-webhook-url", "", "Generic webhook URL (ad-hoc --notify=webhook)")
+	flags.String("notify-smtp-host", "", "SMTP relay host (ad-hoc --notify=smtp)")
+	flags.Int("notify-smtp-port", 587, "SMTP relay port (ad-hoc --notify=smtp)")
+	flags.String("notify-smtp-username", "", "SMTP relay username (ad-hoc --notify=smtp)")
+	flags.String("notify-smtp-password", "", "SMTP relay password (ad-hoc --notify=smtp)")
+	flags.String("notify-smtp-from", "", "SMTP From address (ad-hoc --notify=smtp)")
+	flags.StringSlice("notify-smtp-to", nil, "SMTP recipients used when an app has no owner tag (ad-hoc --notify=smtp)")
+
+	rootCmd.AddCommand(newRotateCommand(&config))
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -0,0 +1,531 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Supported values for NotifierConfig.Type and --notify.
+const (
+	notifierTypeTeams   = "teams"
+	notifierTypeSlack   = "slack"
+	notifierTypeSMTP    = "smtp"
+	notifierTypeWebhook = "webhook"
+)
+
+// Severity buckets produced by severityFor, in escalating order.
+const (
+	severityWarning  = "warning"
+	severityCritical = "critical"
+	severityExpired  = "expired"
+)
+
+// defaultSeverityThresholds is used when --notify-severity-thresholds is
+// not set.
+var defaultSeverityThresholds = map[string]int{
+	severityWarning:  30,
+	severityCritical: 7,
+	severityExpired:  0,
+}
+
+// NotifierConfig declares a single named notifier, as read from the
+// config file's "notifiers" list.
+type NotifierConfig struct {
+	// Name identifies this notifier in NotificationRoute.Notifiers.
+	Name string `mapstructure:"name"`
+	// Type selects the implementation: teams, slack, smtp, or webhook.
+	Type string `mapstructure:"type"`
+	// WebhookURL is the destination for teams, slack, and webhook types.
+	WebhookURL string `mapstructure:"webhook_url"`
+	// SMTP configures the smtp type.
+	SMTP SMTPConfig `mapstructure:"smtp"`
+}
+
+// SMTPConfig configures an smtp-type notifier.
+type SMTPConfig struct {
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port"`
+	// Username/Password authenticate with the relay, if it requires auth.
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	// To is used when the application has no "owner:<email>" tag.
+	To []string `mapstructure:"to"`
+}
+
+// NotificationRoute maps applications matching Pattern (a regex tested
+// against the application display name and its tags) to the notifiers
+// that should be alerted about them.
+type NotificationRoute struct {
+	Pattern   string   `mapstructure:"pattern"`
+	Notifiers []string `mapstructure:"notifiers"`
+}
+
+// Notifier fans a SecretInfo out to an alert destination.
+type Notifier interface {
+	// Name identifies the notifier, matched against NotificationRoute.Notifiers.
+	Name() string
+	// Notify renders and sends (or, in dry-run mode, prints) an alert for
+	// secret at the given severity.
+	Notify(ctx context.Context, secret SecretInfo, severity string, dryRun bool) error
+}
+
+// severityFor buckets daysToExpiry using thresholds, returning "" if the
+// secret isn't due for a notification at all.
+func severityFor(daysToExpiry int, thresholds map[string]int) string {
+	if daysToExpiry <= thresholds[severityExpired] {
+		return severityExpired
+	}
+	if daysToExpiry <= thresholds[severityCritical] {
+		return severityCritical
+	}
+	if daysToExpiry <= thresholds[severityWarning] {
+		return severityWarning
+	}
+	return ""
+}
+
+// parseSeverityThresholds parses a "warning=30,critical=7,expired=0"
+// string as accepted by --notify-severity-thresholds. Unset buckets fall
+// back to defaultSeverityThresholds.
+func parseSeverityThresholds(spec string) (map[string]int, error) {
+	thresholds := map[string]int{
+		severityWarning:  defaultSeverityThresholds[severityWarning],
+		severityCritical: defaultSeverityThresholds[severityCritical],
+		severityExpired:  defaultSeverityThresholds[severityExpired],
+	}
+	if spec == "" {
+		return thresholds, nil
+	}
+
+	for _, pair := range strings.Split(spec, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid notify-severity-thresholds entry %q: expected name=days", pair)
+		}
+
+		name := strings.TrimSpace(parts[0])
+		switch name {
+		case severityWarning, severityCritical, severityExpired:
+		default:
+			return nil, fmt.Errorf("invalid notify-severity-thresholds bucket %q: must be one of warning, critical, expired", name)
+		}
+
+		days, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid notify-severity-thresholds value for %q: %v", name, err)
+		}
+		thresholds[name] = days
+	}
+
+	return thresholds, nil
+}
+
+// ownerTagPattern matches an "owner:<email>" application tag.
+var ownerTagPattern = regexp.MustCompile(`^owner:(.+)$`)
+
+// ownerFromTags returns the email address from the first "owner:<email>"
+// tag found, or "" if none is present.
+func ownerFromTags(tags []string) string {
+	for _, tag := range tags {
+		if match := ownerTagPattern.FindStringSubmatch(tag); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// resolveNotifiers builds the configured named notifiers, keyed by name.
+func resolveNotifiers(configs []NotifierConfig) (map[string]Notifier, error) {
+	notifiers := make(map[string]Notifier, len(configs))
+	for _, nc := range configs {
+		notifier, err := buildNotifier(nc)
+		if err != nil {
+			return nil, fmt.Errorf("notifier %q: %v", nc.Name, err)
+		}
+		notifiers[nc.Name] = notifier
+	}
+	return notifiers, nil
+}
+
+func buildNotifier(nc NotifierConfig) (Notifier, error) {
+	switch nc.Type {
+	case notifierTypeTeams:
+		if nc.WebhookURL == "" {
+			return nil, fmt.Errorf("webhook_url is required for type=teams")
+		}
+		return &TeamsNotifier{name: nc.Name, webhookURL: nc.WebhookURL}, nil
+	case notifierTypeSlack:
+		if nc.WebhookURL == "" {
+			return nil, fmt.Errorf("webhook_url is required for type=slack")
+		}
+		return &SlackNotifier{name: nc.Name, webhookURL: nc.WebhookURL}, nil
+	case notifierTypeWebhook:
+		if nc.WebhookURL == "" {
+			return nil, fmt.Errorf("webhook_url is required for type=webhook")
+		}
+		return &WebhookNotifier{name: nc.Name, webhookURL: nc.WebhookURL}, nil
+	case notifierTypeSMTP:
+		return &SMTPNotifier{name: nc.Name, config: nc.SMTP}, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q: must be one of teams, slack, smtp, webhook", nc.Type)
+	}
+}
+
+// routeNotifiers returns the notifiers that should be alerted for secret,
+// per the first matching route. Routes are tried in order; the first
+// whose pattern matches the application's display name or any of its tags
+// wins.
+func routeNotifiers(secret SecretInfo, routes []NotificationRoute, notifiers map[string]Notifier) ([]Notifier, error) {
+	for _, route := range routes {
+		regex, err := regexp.Compile(route.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid notification route pattern %q: %v", route.Pattern, err)
+		}
+
+		matched := regex.MatchString(secret.ApplicationName)
+		for _, tag := range secret.Tags {
+			if matched {
+				break
+			}
+			matched = regex.MatchString(tag)
+		}
+		if !matched {
+			continue
+		}
+
+		var targets []Notifier
+		for _, name := range route.Notifiers {
+			if notifier, ok := notifiers[name]; ok {
+				targets = append(targets, notifier)
+			}
+		}
+		return targets, nil
+	}
+	return nil, nil
+}
+
+// sendNotifications buckets each secret by severity and fans it out to
+// the notifiers selected either by config.NotificationRoutes (for named
+// notifiers) or, in the simpler ad-hoc mode, every notifier built from
+// --notify.
+func sendNotifications(ctx context.Context, secrets []SecretInfo, config Config) error {
+	thresholds, err := parseSeverityThresholds(config.NotifySeverityThresholds)
+	if err != nil {
+		return err
+	}
+
+	named, err := resolveNotifiers(config.Notifiers)
+	if err != nil {
+		return err
+	}
+
+	adHoc, err := buildAdHocNotifiers(config)
+	if err != nil {
+		return err
+	}
+
+	for _, secret := range secrets {
+		if secret.CredentialType == credentialTypeFederated {
+			continue // FICs never expire; nothing to alert on
+		}
+
+		severity := severityFor(secret.DaysToExpiry, thresholds)
+		if severity == "" {
+			continue
+		}
+
+		targets, err := routeNotifiers(secret, config.NotificationRoutes, named)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, adHoc...)
+
+		for _, notifier := range targets {
+			if err := notifier.Notify(ctx, secret, severity, config.NotifyDryRun); err != nil {
+				return fmt.Errorf("notifier %q failed for app %s: %v", notifier.Name(), secret.ApplicationID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// buildAdHocNotifiers builds one notifier per --notify type, configured
+// from the matching --notify-*-webhook-url/--notify-smtp-* flags. This is
+// the simple "fire to one destination" path; multi-notifier routing
+// requires named notifiers declared in the config file.
+func buildAdHocNotifiers(config Config) ([]Notifier, error) {
+	var notifiers []Notifier
+	for _, t := range config.NotifyTypes {
+		switch t {
+		case notifierTypeTeams:
+			if config.NotifyTeamsWebhookURL == "" {
+				return nil, fmt.Errorf("--notify-teams-webhook-url is required for --notify=teams")
+			}
+			notifiers = append(notifiers, &TeamsNotifier{name: notifierTypeTeams, webhookURL: config.NotifyTeamsWebhookURL})
+		case notifierTypeSlack:
+			if config.NotifySlackWebhookURL == "" {
+				return nil, fmt.Errorf("--notify-slack-webhook-url is required for --notify=slack")
+			}
+			notifiers = append(notifiers, &SlackNotifier{name: notifierTypeSlack, webhookURL: config.NotifySlackWebhookURL})
+		case notifierTypeWebhook:
+			if config.NotifyWebhookURL == "" {
+				return nil, fmt.Errorf("--notify-webhook-url is required for --notify=webhook")
+			}
+			notifiers = append(notifiers, &WebhookNotifier{name: notifierTypeWebhook, webhookURL: config.NotifyWebhookURL})
+		case notifierTypeSMTP:
+			if config.NotifySMTPHost == "" {
+				return nil, fmt.Errorf("--notify-smtp-host is required for --notify=smtp")
+			}
+			notifiers = append(notifiers, &SMTPNotifier{name: notifierTypeSMTP, config: SMTPConfig{
+				Host:     config.NotifySMTPHost,
+				Port:     config.NotifySMTPPort,
+				Username: config.NotifySMTPUsername,
+				Password: config.NotifySMTPPassword,
+				From:     config.NotifySMTPFrom,
+				To:       config.NotifySMTPTo,
+			}})
+		default:
+			return nil, fmt.Errorf("unknown notify type %q: must be one of teams, slack, smtp, webhook", t)
+		}
+	}
+	return notifiers, nil
+}
+
+// severityColor maps a severity bucket to the theme color used in Teams
+// cards. Slack messages carry no color, only severityIcon.
+func severityColor(severity string) string {
+	switch severity {
+	case severityExpired:
+		return "A80000"
+	case severityCritical:
+		return "D83B01"
+	default:
+		return "FFB900"
+	}
+}
+
+// severityIcon maps a severity bucket to the icon used in Teams/Slack
+// cards.
+func severityIcon(severity string) string {
+	switch severity {
+	case severityExpired:
+		return "🛑"
+	case severityCritical:
+		return "🔴"
+	default:
+		return "🟡"
+	}
+}
+
+// postJSON sends body as a JSON POST to url, or prints it instead if
+// dryRun is set.
+func postJSON(ctx context.Context, url string, body []byte, dryRun bool) error {
+	if dryRun {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// TeamsNotifier posts a MessageCard (the legacy Office 365 connector card
+// format) to a Microsoft Teams Incoming Webhook. It does not send an
+// Adaptive Card.
+type TeamsNotifier struct {
+	name       string
+	webhookURL string
+}
+
+// Name implements Notifier.
+func (n *TeamsNotifier) Name() string { return n.name }
+
+// Notify implements Notifier.
+func (n *TeamsNotifier) Notify(ctx context.Context, secret SecretInfo, severity string, dryRun bool) error {
+	card := map[string]any{
+		"@type":      "MessageCard",
+		"@context":   "http://schema.org/extensions",
+		"summary":    fmt.Sprintf("%s secret expiring", secret.ApplicationName),
+		"themeColor": severityColor(severity),
+		"title":      fmt.Sprintf("%s %s: %s secret %s", severityIcon(severity), strings.ToUpper(severity), secret.CredentialType, secret.ApplicationName),
+		"sections": []map[string]any{
+			{
+				"facts": []map[string]string{
+					{"name": "Application", "value": secret.ApplicationName},
+					{"name": "App ID", "value": secret.ApplicationID},
+					{"name": "Secret ID", "value": secret.SecretID},
+					{"name": "Expiry Date", "value": secret.ExpiryDate},
+					{"name": "Days To Expiry", "value": fmt.Sprintf("%d", secret.DaysToExpiry)},
+				},
+			},
+		},
+	}
+
+	body, err := json.Marshal(card)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, n.webhookURL, body, dryRun)
+}
+
+// SlackNotifier posts a Block Kit message to a Slack Incoming Webhook.
+type SlackNotifier struct {
+	name       string
+	webhookURL string
+}
+
+// Name implements Notifier.
+func (n *SlackNotifier) Name() string { return n.name }
+
+// Notify implements Notifier.
+func (n *SlackNotifier) Notify(ctx context.Context, secret SecretInfo, severity string, dryRun bool) error {
+	text := fmt.Sprintf("%s *%s*: `%s` secret for *%s* expires %s (%d days)",
+		severityIcon(severity), strings.ToUpper(severity), secret.CredentialType, secret.ApplicationName, secret.ExpiryDate, secret.DaysToExpiry)
+
+	message := map[string]any{
+		"blocks": []map[string]any{
+			{
+				"type": "section",
+				"text": map[string]string{
+					"type": "mrkdwn",
+					"text": text,
+				},
+			},
+		},
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, n.webhookURL, body, dryRun)
+}
+
+// WebhookNotifier POSTs the raw SecretInfo as JSON to a generic webhook.
+type WebhookNotifier struct {
+	name       string
+	webhookURL string
+}
+
+// Name implements Notifier.
+func (n *WebhookNotifier) Name() string { return n.name }
+
+// Notify implements Notifier.
+func (n *WebhookNotifier) Notify(ctx context.Context, secret SecretInfo, severity string, dryRun bool) error {
+	payload := struct {
+		Severity string `json:"severity"`
+		SecretInfo
+	}{Severity: severity, SecretInfo: secret}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, n.webhookURL, body, dryRun)
+}
+
+// SMTPNotifier emails a templated alert via a configurable relay. The
+// recipient is the application's "owner:<email>" tag if present,
+// otherwise config.To.
+type SMTPNotifier struct {
+	name   string
+	config SMTPConfig
+}
+
+// Name implements Notifier.
+func (n *SMTPNotifier) Name() string { return n.name }
+
+// Notify implements Notifier.
+func (n *SMTPNotifier) Notify(ctx context.Context, secret SecretInfo, severity string, dryRun bool) error {
+	to := n.config.To
+	if owner := ownerFromTags(secret.Tags); owner != "" {
+		to = []string{owner}
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("no recipient: application has no owner tag and no default 'to' is configured")
+	}
+	for _, addr := range to {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return fmt.Errorf("invalid recipient address %q: %v", addr, err)
+		}
+	}
+
+	applicationName := sanitizeHeaderValue(secret.ApplicationName)
+	credentialType := sanitizeHeaderValue(secret.CredentialType)
+
+	subject := fmt.Sprintf("[%s] %s secret expiring for %s", strings.ToUpper(severity), credentialType, applicationName)
+	htmlBody := fmt.Sprintf(`<h2>%s secret expiring soon</h2>
+<p><b>Application:</b> %s<br>
+<b>App ID:</b> %s<br>
+<b>Secret ID:</b> %s<br>
+<b>Expiry Date:</b> %s<br>
+<b>Days To Expiry:</b> %d<br>
+<b>Severity:</b> %s</p>`,
+		credentialType, applicationName, secret.ApplicationID, secret.SecretID, secret.ExpiryDate, secret.DaysToExpiry, severity)
+
+	header := make(mail.Header)
+	header["From"] = []string{n.config.From}
+	header["To"] = []string{strings.Join(to, ", ")}
+	header["Subject"] = []string{subject}
+	header["MIME-Version"] = []string{"1.0"}
+	header["Content-Type"] = []string{`text/html; charset="UTF-8"`}
+
+	var buf bytes.Buffer
+	for key, values := range header {
+		for _, value := range values {
+			fmt.Fprintf(&buf, "%s: %s\r\n", key, sanitizeHeaderValue(value))
+		}
+	}
+	buf.WriteString("\r\n")
+	buf.WriteString(htmlBody)
+	message := buf.Bytes()
+
+	if dryRun {
+		fmt.Println(string(message))
+		return nil
+	}
+
+	addr := fmt.Sprintf("%s:%d", n.config.Host, n.config.Port)
+	var auth smtp.Auth
+	if n.config.Username != "" {
+		auth = smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)
+	}
+
+	if err := smtp.SendMail(addr, auth, n.config.From, to, message); err != nil {
+		return fmt.Errorf("failed to send mail via %s: %v", addr, err)
+	}
+	return nil
+}
+
+// sanitizeHeaderValue strips CR and LF from s so it cannot be used to
+// inject additional headers (or corrupt the message) via an
+// attacker-controlled display name, tag, or other field.
+func sanitizeHeaderValue(s string) string {
+	s = strings.ReplaceAll(s, "\r", "")
+	s = strings.ReplaceAll(s, "\n", "")
+	return s
+}
@@ -0,0 +1,108 @@
+package main
+
+import "testing"
+
+func TestSeverityFor(t *testing.T) {
+	thresholds := map[string]int{
+		severityWarning:  30,
+		severityCritical: 7,
+		severityExpired:  0,
+	}
+
+	tests := []struct {
+		daysToExpiry int
+		want         string
+	}{
+		{-5, severityExpired},
+		{0, severityExpired},
+		{1, severityCritical},
+		{7, severityCritical},
+		{8, severityWarning},
+		{30, severityWarning},
+		{31, ""},
+	}
+
+	for _, tt := range tests {
+		if got := severityFor(tt.daysToExpiry, thresholds); got != tt.want {
+			t.Errorf("severityFor(%d, ...) = %q, want %q", tt.daysToExpiry, got, tt.want)
+		}
+	}
+}
+
+func TestParseSeverityThresholds(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string]int
+		wantErr bool
+	}{
+		{
+			name: "empty spec falls back to defaults",
+			spec: "",
+			want: defaultSeverityThresholds,
+		},
+		{
+			name: "overrides one bucket",
+			spec: "critical=14",
+			want: map[string]int{severityWarning: 30, severityCritical: 14, severityExpired: 0},
+		},
+		{
+			name: "overrides all buckets with spacing",
+			spec: "warning=45, critical=10, expired=1",
+			want: map[string]int{severityWarning: 45, severityCritical: 10, severityExpired: 1},
+		},
+		{
+			name:    "unknown bucket",
+			spec:    "urgent=5",
+			wantErr: true,
+		},
+		{
+			name:    "missing value",
+			spec:    "warning",
+			wantErr: true,
+		},
+		{
+			name:    "non-integer value",
+			spec:    "warning=soon",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSeverityThresholds(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseSeverityThresholds(%q) returned nil error, want one", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSeverityThresholds(%q) returned error: %v", tt.spec, err)
+			}
+			for bucket, want := range tt.want {
+				if got[bucket] != want {
+					t.Errorf("parseSeverityThresholds(%q)[%q] = %d, want %d", tt.spec, bucket, got[bucket], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizeHeaderValue(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"normal subject", "normal subject"},
+		{"evil\r\nBcc: attacker@example.com", "evilBcc: attacker@example.com"},
+		{"line1\nline2", "line1line2"},
+		{"line1\r", "line1"},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizeHeaderValue(tt.in); got != tt.want {
+			t.Errorf("sanitizeHeaderValue(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported values for --format.
+const (
+	formatText     = "text"
+	formatJSON     = "json"
+	formatCSV      = "csv"
+	formatYAML     = "yaml"
+	formatTable    = "table"
+	formatTemplate = "template"
+)
+
+// Outputter renders a collected batch of secrets in a specific structured
+// format.
+type Outputter interface {
+	// Format identifies the outputter as used in --format.
+	Format() string
+	// Render writes secrets to w.
+	Render(w io.Writer, secrets []SecretInfo, config Config) error
+}
+
+// buildOutputter resolves config.Format into an Outputter.
+func buildOutputter(config Config) (Outputter, error) {
+	switch config.Format {
+	case formatText, "":
+		return TextOutputter{}, nil
+	case formatJSON:
+		return JSONOutputter{}, nil
+	case formatCSV:
+		return CSVOutputter{}, nil
+	case formatYAML:
+		return YAMLOutputter{}, nil
+	case formatTable:
+		return TableOutputter{}, nil
+	case formatTemplate:
+		if config.TemplateFile == "" {
+			return nil, fmt.Errorf("--template-file is required for format=template")
+		}
+		return TemplateOutputter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q: must be one of text, json, csv, yaml, table, template", config.Format)
+	}
+}
+
+// TextOutputter renders secrets in the tool's original human-readable
+// report format.
+type TextOutputter struct{}
+
+// Format implements Outputter.
+func (TextOutputter) Format() string { return formatText }
+
+// Render implements Outputter.
+func (TextOutputter) Render(w io.Writer, secrets []SecretInfo, config Config) error {
+	printText(w, secrets, config)
+	return nil
+}
+
+// JSONOutputter renders secrets as the tool's original structured JSON
+// report, alongside an ExecutionInfo block.
+type JSONOutputter struct{}
+
+// Format implements Outputter.
+func (JSONOutputter) Format() string { return formatJSON }
+
+// Render implements Outputter.
+func (JSONOutputter) Render(w io.Writer, secrets []SecretInfo, config Config) error {
+	return printJSON(w, secrets, config)
+}
+
+// csvColumns are the SecretInfo fields every row of CSVOutputter writes,
+// in order.
+var csvColumns = []string{
+	"application_name", "application_id", "secret_id", "credential_type",
+	"expiry_date", "days_to_expiry", "thumbprint", "subject", "issuer",
+	"audience", "tags",
+}
+
+// CSVOutputter renders secrets as CSV, one row per secret.
+type CSVOutputter struct{}
+
+// Format implements Outputter.
+func (CSVOutputter) Format() string { return formatCSV }
+
+// Render implements Outputter.
+func (CSVOutputter) Render(w io.Writer, secrets []SecretInfo, config Config) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return fmt.Errorf("failed to write CSV header: %v", err)
+	}
+
+	for _, secret := range secrets {
+		row := []string{
+			secret.ApplicationName,
+			secret.ApplicationID,
+			secret.SecretID,
+			secret.CredentialType,
+			secret.ExpiryDate,
+			strconv.Itoa(secret.DaysToExpiry),
+			secret.Thumbprint,
+			secret.Subject,
+			secret.Issuer,
+			strings.Join(secret.Audience, ";"),
+			strings.Join(secret.Tags, ";"),
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %v", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// YAMLOutputter renders secrets as a YAML sequence.
+type YAMLOutputter struct{}
+
+// Format implements Outputter.
+func (YAMLOutputter) Format() string { return formatYAML }
+
+// Render implements Outputter.
+func (YAMLOutputter) Render(w io.Writer, secrets []SecretInfo, config Config) error {
+	if secrets == nil {
+		secrets = []SecretInfo{}
+	}
+	encoder := yaml.NewEncoder(w)
+	defer encoder.Close()
+	return encoder.Encode(secrets)
+}
+
+// TableOutputter renders secrets as an aligned, human-scannable table.
+type TableOutputter struct{}
+
+// Format implements Outputter.
+func (TableOutputter) Format() string { return formatTable }
+
+// Render implements Outputter.
+func (TableOutputter) Render(w io.Writer, secrets []SecretInfo, config Config) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "APPLICATION\tAPP ID\tSECRET ID\tTYPE\tEXPIRY DATE\tDAYS TO EXPIRY")
+	for _, secret := range secrets {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\n",
+			secret.ApplicationName, secret.ApplicationID, secret.SecretID,
+			secret.CredentialType, secret.ExpiryDate, secret.DaysToExpiry)
+	}
+	return tw.Flush()
+}
+
+// TemplateOutputter renders secrets using the Go template at
+// config.TemplateFile, with the secret slice as the template's root data.
+type TemplateOutputter struct{}
+
+// Format implements Outputter.
+func (TemplateOutputter) Format() string { return formatTemplate }
+
+// Render implements Outputter.
+func (TemplateOutputter) Render(w io.Writer, secrets []SecretInfo, config Config) error {
+	body, err := os.ReadFile(config.TemplateFile)
+	if err != nil {
+		return fmt.Errorf("failed to read template file %s: %v", config.TemplateFile, err)
+	}
+
+	tmpl, err := template.New(config.TemplateFile).Parse(string(body))
+	if err != nil {
+		return fmt.Errorf("failed to parse template file %s: %v", config.TemplateFile, err)
+	}
+
+	return tmpl.Execute(w, secrets)
+}
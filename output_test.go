@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var outputTestSecrets = []SecretInfo{
+	{
+		ApplicationName: "billing-service",
+		ApplicationID:   "app-1",
+		SecretID:        "secret-1",
+		CredentialType:  credentialTypePassword,
+		ExpiryDate:      "2026-08-01",
+		DaysToExpiry:    3,
+		Tags:            []string{"owner:a@example.com", "env:prod"},
+	},
+}
+
+func TestCSVOutputterRender(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (CSVOutputter{}).Render(&buf, outputTestSecrets, Config{}); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2 (header + 1 row): %q", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "application_name") {
+		t.Errorf("header %q missing application_name column", lines[0])
+	}
+	if !strings.Contains(lines[1], "billing-service") || !strings.Contains(lines[1], "owner:a@example.com;env:prod") {
+		t.Errorf("row %q missing expected fields", lines[1])
+	}
+}
+
+func TestTableOutputterRender(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (TableOutputter{}).Render(&buf, outputTestSecrets, Config{}); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "APPLICATION") {
+		t.Errorf("table output missing header: %q", out)
+	}
+	if !strings.Contains(out, "billing-service") {
+		t.Errorf("table output missing secret row: %q", out)
+	}
+}
+
+func TestYAMLOutputterRenderHandlesEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (YAMLOutputter{}).Render(&buf, nil, Config{}); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("Render(nil) = %q, want an empty YAML sequence", buf.String())
+	}
+}
+
+func TestBuildOutputter(t *testing.T) {
+	tests := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{format: "", want: formatText},
+		{format: formatText, want: formatText},
+		{format: formatJSON, want: formatJSON},
+		{format: formatCSV, want: formatCSV},
+		{format: formatYAML, want: formatYAML},
+		{format: formatTable, want: formatTable},
+		{format: "bogus", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		outputter, err := buildOutputter(Config{Format: tt.format})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("buildOutputter(%q) returned nil error, want one", tt.format)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("buildOutputter(%q) returned error: %v", tt.format, err)
+		}
+		if outputter.Format() != tt.want {
+			t.Errorf("buildOutputter(%q).Format() = %q, want %q", tt.format, outputter.Format(), tt.want)
+		}
+	}
+
+	if _, err := buildOutputter(Config{Format: formatTemplate}); err == nil {
+		t.Error("buildOutputter(template) with no TemplateFile returned nil error, want one")
+	}
+}
@@ -0,0 +1,608 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/keyvault/azsecrets"
+	"github.com/google/uuid"
+	"github.com/microsoftgraph/msgraph-sdk-go/applications"
+	graphmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
+	"github.com/spf13/cobra"
+)
+
+// Supported values for --rotation-sink.
+const (
+	rotationSinkStdoutJSON  = "stdout-json"
+	rotationSinkKeyVault    = "keyvault"
+	rotationSinkAzureDevOps = "azuredevops"
+)
+
+// expiringCredential pairs a password credential nearing expiry with the
+// application it belongs to. The application's object ID (as opposed to
+// its appId) is required by the Graph addPassword/removePassword actions.
+type expiringCredential struct {
+	AppObjectID string
+	AppID       string
+	DisplayName string
+	KeyID       string
+	// Siblings holds every password credential currently on the
+	// application that has a display name, this one included, used to
+	// find a prior run's replacement (see
+	// findReplacement) when RetireOldAfterDays is set.
+	Siblings []passwordCredentialInfo
+}
+
+// passwordCredentialInfo is the minimal identity of a password credential
+// needed to recognize a rotation marker left by rotationDisplayName.
+type passwordCredentialInfo struct {
+	KeyID       string
+	DisplayName string
+}
+
+// RotationOptions configures how RotateSecrets mints and retires secrets.
+type RotationOptions struct {
+	// NewSecretLifetimeDays is how long the replacement secret is valid for.
+	NewSecretLifetimeDays int
+	// NewSecretDisplayName names the replacement PasswordCredential. If
+	// empty, rotationDisplayName is used, which also marks the credential
+	// it replaces so a later run can find it again for retirement. Must be
+	// empty when RetireOldAfterDays > 0 -- newRotateCommand rejects that
+	// combination up front, since a custom name carries no such marker and
+	// the superseded credential would never be found for retirement.
+	NewSecretDisplayName string
+	// RetireOldAfterDays is the grace period before the old credential is
+	// removed. A value <= 0 retires it immediately after all sinks confirm
+	// delivery; a positive value leaves it in place and retires it on a
+	// later run, once that run's default-named replacement marker (see
+	// rotationDisplayName) shows the grace period has elapsed.
+	RetireOldAfterDays int
+}
+
+// RotationResult is the replacement secret minted for a single application,
+// as handed to every configured RotationSink.
+type RotationResult struct {
+	AppID       string `json:"app_id"`
+	KeyID       string `json:"key_id"`
+	SecretText  string `json:"secret_text"`
+	EndDate     string `json:"end_date"`
+	DisplayName string `json:"display_name"`
+}
+
+// RotationSink propagates a newly minted secret to a downstream system.
+// Deliver is called for every configured sink, for every rotated
+// application, before that application's old credential is retired --
+// Graph only ever returns a password's cleartext value once, at creation.
+type RotationSink interface {
+	// Name identifies the sink as used in --rotation-sink.
+	Name() string
+	// Deliver hands the rotated secret to the sink.
+	Deliver(ctx context.Context, cred expiringCredential, result RotationResult) error
+}
+
+// RotateSecrets mints a replacement client secret for every application
+// whose password credential is within the expiry threshold, delivers it to
+// each sink in turn, and retires the old credential once every sink has
+// confirmed delivery (unless a retirement grace period was requested).
+func (m *Monitor) RotateSecrets(ctx context.Context, opts RotationOptions, sinks []RotationSink) ([]RotationResult, error) {
+	candidates, err := m.findExpiringPasswordCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []RotationResult
+	for _, cred := range candidates {
+		if opts.RetireOldAfterDays > 0 {
+			if mintedAt, ok := findReplacement(cred); ok {
+				// A prior run already minted a replacement for this
+				// credential; it is only here again because it is still
+				// within the expiry threshold. Retire it once the grace
+				// period has elapsed and leave it alone otherwise, rather
+				// than minting yet another replacement.
+				if time.Since(mintedAt) < time.Duration(opts.RetireOldAfterDays)*24*time.Hour {
+					continue
+				}
+				if err := m.removePassword(ctx, cred); err != nil {
+					return results, fmt.Errorf("failed to retire old secret for app %s: %v", cred.AppID, err)
+				}
+				continue
+			}
+		}
+
+		result, err := m.addPassword(ctx, cred, opts)
+		if err != nil {
+			return results, fmt.Errorf("failed to rotate secret for app %s: %v", cred.AppID, err)
+		}
+
+		for _, sink := range sinks {
+			if err := sink.Deliver(ctx, cred, result); err != nil {
+				return results, fmt.Errorf("sink %q failed to deliver rotated secret for app %s: %v", sink.Name(), cred.AppID, err)
+			}
+		}
+
+		results = append(results, result)
+
+		if opts.RetireOldAfterDays <= 0 {
+			if err := m.removePassword(ctx, cred); err != nil {
+				return results, fmt.Errorf("failed to retire old secret for app %s: %v", cred.AppID, err)
+			}
+		}
+		// A positive RetireOldAfterDays leaves cred in place; the
+		// replacement minted above is marked (rotationDisplayName) so a
+		// later run's findReplacement can recognize cred as superseded
+		// and retire it once the grace period elapses.
+	}
+
+	return results, nil
+}
+
+// findReplacement looks for a sibling credential minted by a previous
+// rotation of cred, as identified by the "-replaces-<KeyID>" marker
+// rotationDisplayName embeds in its default display name. ok is false if
+// no such marker is found, e.g. cred hasn't been rotated yet, or its
+// replacement was given a custom --new-secret-display-name.
+func findReplacement(cred expiringCredential) (mintedAt time.Time, ok bool) {
+	for _, sibling := range cred.Siblings {
+		mintedAt, replacesKeyID, markerOK := parseRotationMarker(sibling.DisplayName)
+		if markerOK && replacesKeyID == cred.KeyID {
+			return mintedAt, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// findExpiringPasswordCredentials walks the monitored applications and
+// returns the password credentials within the configured expiry threshold,
+// along with the application object ID needed to rotate them.
+func (m *Monitor) findExpiringPasswordCredentials(ctx context.Context) ([]expiringCredential, error) {
+	apps, err := m.listMonitoredApps(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []expiringCredential
+	for _, app := range apps {
+		objectID := app.GetId()
+		appID := app.GetAppId()
+		displayName := app.GetDisplayName()
+		if objectID == nil || appID == nil || displayName == nil {
+			continue
+		}
+
+		var siblings []passwordCredentialInfo
+		for _, cred := range app.GetPasswordCredentials() {
+			keyID := cred.GetKeyId()
+			name := cred.GetDisplayName()
+			if keyID == nil || name == nil {
+				continue
+			}
+			siblings = append(siblings, passwordCredentialInfo{KeyID: keyID.String(), DisplayName: *name})
+		}
+
+		for _, cred := range app.GetPasswordCredentials() {
+			endDateTime := cred.GetEndDateTime()
+			if endDateTime == nil {
+				continue
+			}
+
+			daysToExpiry := int(time.Until(endDateTime.UTC()).Hours() / 24)
+			if daysToExpiry > m.config.ExpiryThresholdDays {
+				continue
+			}
+
+			keyID := cred.GetKeyId()
+			if keyID == nil {
+				continue
+			}
+
+			found = append(found, expiringCredential{
+				AppObjectID: *objectID,
+				AppID:       *appID,
+				DisplayName: *displayName,
+				KeyID:       keyID.String(),
+				Siblings:    siblings,
+			})
+		}
+	}
+
+	return found, nil
+}
+
+// addPassword calls Graph's addPassword action to mint a replacement
+// PasswordCredential for the application.
+func (m *Monitor) addPassword(ctx context.Context, cred expiringCredential, opts RotationOptions) (RotationResult, error) {
+	displayName := opts.NewSecretDisplayName
+	if displayName == "" {
+		displayName = rotationDisplayName(cred.KeyID)
+	}
+
+	passwordCredential := graphmodels.NewPasswordCredential()
+	passwordCredential.SetDisplayName(&displayName)
+	endDateTime := time.Now().UTC().AddDate(0, 0, opts.NewSecretLifetimeDays)
+	passwordCredential.SetEndDateTime(&endDateTime)
+
+	requestBody := applications.NewItemAddPasswordPostRequestBody()
+	requestBody.SetPasswordCredential(passwordCredential)
+
+	added, err := m.client.Applications().ByApplicationId(cred.AppObjectID).AddPassword().Post(ctx, requestBody, nil)
+	if err != nil {
+		return RotationResult{}, fmt.Errorf("addPassword failed: %v", err)
+	}
+
+	secretText := added.GetSecretText()
+	keyID := added.GetKeyId()
+	endDate := added.GetEndDateTime()
+	if secretText == nil || keyID == nil || endDate == nil {
+		return RotationResult{}, fmt.Errorf("Graph returned an incomplete password credential")
+	}
+
+	return RotationResult{
+		AppID:       cred.AppID,
+		KeyID:       keyID.String(),
+		SecretText:  *secretText,
+		EndDate:     endDate.Format("2006-01-02"),
+		DisplayName: displayName,
+	}, nil
+}
+
+// rotationDisplayName builds the default display name for a freshly
+// minted replacement secret. It embeds the mint time and the key ID of
+// the credential it replaces, so a later run can recognize that
+// credential as superseded via parseRotationMarker and retire it once
+// RetireOldAfterDays has elapsed.
+func rotationDisplayName(replacesKeyID string) string {
+	return fmt.Sprintf("auto-rotated-%d-replaces-%s", time.Now().UTC().Unix(), replacesKeyID)
+}
+
+// parseRotationMarker extracts the mint time and superseded key ID from a
+// display name produced by rotationDisplayName. ok is false if name
+// doesn't match that format, e.g. it was minted with a custom
+// --new-secret-display-name or wasn't minted by this tool at all.
+func parseRotationMarker(name string) (mintedAt time.Time, replacesKeyID string, ok bool) {
+	rest := strings.TrimPrefix(name, "auto-rotated-")
+	if rest == name {
+		return time.Time{}, "", false
+	}
+
+	parts := strings.SplitN(rest, "-replaces-", 2)
+	if len(parts) != 2 {
+		return time.Time{}, "", false
+	}
+
+	unixTS, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return time.Unix(unixTS, 0).UTC(), parts[1], true
+}
+
+// removePassword calls Graph's removePassword action to retire the
+// credential identified by cred.KeyID.
+func (m *Monitor) removePassword(ctx context.Context, cred expiringCredential) error {
+	keyID, err := uuid.Parse(cred.KeyID)
+	if err != nil {
+		return fmt.Errorf("invalid key ID %q: %v", cred.KeyID, err)
+	}
+
+	requestBody := applications.NewItemRemovePasswordPostRequestBody()
+	requestBody.SetKeyId(&keyID)
+
+	return m.client.Applications().ByApplicationId(cred.AppObjectID).RemovePassword().Post(ctx, requestBody, nil)
+}
+
+// StdoutJSONSink emits the rotated secret as a JSON line on stdout so an
+// operator can pipe it into their own automation.
+type StdoutJSONSink struct{}
+
+// Name implements RotationSink.
+func (StdoutJSONSink) Name() string { return rotationSinkStdoutJSON }
+
+// Deliver implements RotationSink.
+func (StdoutJSONSink) Deliver(ctx context.Context, cred expiringCredential, result RotationResult) error {
+	encoder := json.NewEncoder(os.Stdout)
+	return encoder.Encode(result)
+}
+
+// KeyVaultSink writes the rotated secret into an Azure Key Vault secret
+// named after the application, using the same credential chain as the
+// rest of the tool.
+type KeyVaultSink struct {
+	client *azsecrets.Client
+}
+
+// NewKeyVaultSink creates a KeyVaultSink against the given vault URL.
+func NewKeyVaultSink(vaultURL string, cred azcore.TokenCredential) (*KeyVaultSink, error) {
+	client, err := azsecrets.NewClient(vaultURL, cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Key Vault client: %v", err)
+	}
+	return &KeyVaultSink{client: client}, nil
+}
+
+// Name implements RotationSink.
+func (s *KeyVaultSink) Name() string { return rotationSinkKeyVault }
+
+// Deliver implements RotationSink.
+func (s *KeyVaultSink) Deliver(ctx context.Context, cred expiringCredential, result RotationResult) error {
+	secretName := keyVaultSecretName(cred.DisplayName)
+	_, err := s.client.SetSecret(ctx, secretName, azsecrets.SetSecretParameters{
+		Value: &result.SecretText,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to write secret %q to Key Vault: %v", secretName, err)
+	}
+	return nil
+}
+
+// keyVaultSecretName sanitizes an application display name into a valid
+// Key Vault secret name (alphanumeric and hyphens only).
+func keyVaultSecretName(displayName string) string {
+	var b strings.Builder
+	for _, r := range displayName {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// AzureDevOpsSink patches matching AzureRM service connections with the
+// rotated secret and triggers Azure DevOps's own endpoint verification.
+type AzureDevOpsSink struct {
+	orgURL string
+	pat    string
+	http   *http.Client
+}
+
+// NewAzureDevOpsSink creates an AzureDevOpsSink for the given organization
+// URL, authenticating with the personal access token pat.
+func NewAzureDevOpsSink(orgURL, pat string) *AzureDevOpsSink {
+	return &AzureDevOpsSink{
+		orgURL: strings.TrimRight(orgURL, "/"),
+		pat:    pat,
+		http:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name implements RotationSink.
+func (s *AzureDevOpsSink) Name() string { return rotationSinkAzureDevOps }
+
+// azdoServiceEndpoint is the subset of an Azure DevOps service endpoint
+// that the sink needs to read and patch.
+type azdoServiceEndpoint struct {
+	ID            string `json:"id"`
+	Authorization struct {
+		Parameters struct {
+			ServicePrincipalID string `json:"serviceprincipalid"`
+		} `json:"parameters"`
+	} `json:"authorization"`
+}
+
+// Deliver implements RotationSink. It enumerates AzureRM service
+// connections whose service principal ID matches the rotated application,
+// patches each with the new secret, and triggers Azure DevOps's
+// verification call for the endpoint.
+func (s *AzureDevOpsSink) Deliver(ctx context.Context, cred expiringCredential, result RotationResult) error {
+	endpoints, err := s.findServiceEndpoints(ctx, cred.AppID)
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range endpoints {
+		if err := s.patchServiceEndpoint(ctx, endpoint.ID, result.SecretText); err != nil {
+			return err
+		}
+		if err := s.verifyServiceEndpoint(ctx, endpoint.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *AzureDevOpsSink) findServiceEndpoints(ctx context.Context, servicePrincipalID string) ([]azdoServiceEndpoint, error) {
+	url := fmt.Sprintf("%s/_apis/serviceendpoint/endpoints?type=azurerm&api-version=7.1", s.orgURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.authenticate(req)
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service endpoints: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list service endpoints: unexpected status %d", resp.StatusCode)
+	}
+
+	var page struct {
+		Value []azdoServiceEndpoint `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode service endpoints: %v", err)
+	}
+
+	var matched []azdoServiceEndpoint
+	for _, endpoint := range page.Value {
+		if endpoint.Authorization.Parameters.ServicePrincipalID == servicePrincipalID {
+			matched = append(matched, endpoint)
+		}
+	}
+	return matched, nil
+}
+
+func (s *AzureDevOpsSink) patchServiceEndpoint(ctx context.Context, endpointID, secretText string) error {
+	url := fmt.Sprintf("%s/_apis/serviceendpoint/endpoints/%s?api-version=7.1", s.orgURL, endpointID)
+	body, err := json.Marshal(map[string]any{
+		"authorization": map[string]any{
+			"parameters": map[string]any{
+				"serviceprincipalkey": secretText,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	s.authenticate(req)
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to patch service endpoint %s: %v", endpointID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to patch service endpoint %s: unexpected status %d", endpointID, resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *AzureDevOpsSink) verifyServiceEndpoint(ctx context.Context, endpointID string) error {
+	url := fmt.Sprintf("%s/_apis/serviceendpoint/endpoints/%s/execute?api-version=7.1", s.orgURL, endpointID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	s.authenticate(req)
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to verify service endpoint %s: %v", endpointID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to verify service endpoint %s: unexpected status %d", endpointID, resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *AzureDevOpsSink) authenticate(req *http.Request) {
+	req.SetBasicAuth("", s.pat)
+}
+
+// newRotateCommand builds the `rotate` subcommand, which generates new
+// client secrets for expiring applications and propagates them to the
+// sinks selected via --rotation-sink.
+func newRotateCommand(config *Config) *cobra.Command {
+	var (
+		rotationSinkNames []string
+		keyVaultURL       string
+		azdoOrgURL        string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Generate new client secrets for expiring applications",
+		Long: `Rotate mints a replacement client secret for every monitored application
+whose secret is within the expiry threshold, delivers it to the configured
+rotation sinks, and retires the old secret once every sink has confirmed
+delivery. With --retire-old-after-days > 0 the old secret is left in place
+and retired by a later run once that grace period has elapsed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			monitor, err := NewMonitor(*config)
+			if err != nil {
+				return fmt.Errorf("failed to create monitor: %v", err)
+			}
+
+			sinks, err := buildRotationSinks(rotationSinkNames, keyVaultURL, azdoOrgURL, *config)
+			if err != nil {
+				return err
+			}
+
+			opts := RotationOptions{
+				NewSecretLifetimeDays: config.RotateNewSecretLifetimeDays,
+				NewSecretDisplayName:  config.RotateNewSecretDisplayName,
+				RetireOldAfterDays:    config.RotateRetireOldAfterDays,
+			}
+
+			ctx := context.Background()
+			results, err := monitor.RotateSecrets(ctx, opts, sinks)
+			if err != nil {
+				return fmt.Errorf("rotation failed: %v", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Rotated %d secret(s).\n", len(results))
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.Int("new-secret-lifetime-days", 180, "Lifetime in days for the newly minted secret")
+	flags.String("new-secret-display-name", "", "Display name for the newly minted secret (default auto-rotated-<unix timestamp>-replaces-<old key ID>); cannot be combined with --retire-old-after-days > 0")
+	flags.Int("retire-old-after-days", 0, "Grace period before the old secret is removed; 0 retires it immediately. Requires the default --new-secret-display-name, which marks the credential it replaces")
+	flags.StringSliceVar(&rotationSinkNames, "rotation-sink", []string{rotationSinkStdoutJSON}, "Sink(s) to deliver rotated secrets to (stdout-json/keyvault/azuredevops), may be repeated")
+	flags.StringVar(&keyVaultURL, "keyvault-url", "", "Key Vault URL to write rotated secrets to (required for rotation-sink=keyvault)")
+	flags.StringVar(&azdoOrgURL, "azdo-org-url", "", "Azure DevOps organization URL (required for rotation-sink=azuredevops); PAT is read from AZDO_PAT")
+
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		config.RotateNewSecretLifetimeDays, _ = flags.GetInt("new-secret-lifetime-days")
+		config.RotateNewSecretDisplayName, _ = flags.GetString("new-secret-display-name")
+		config.RotateRetireOldAfterDays, _ = flags.GetInt("retire-old-after-days")
+
+		if config.RotateRetireOldAfterDays > 0 && config.RotateNewSecretDisplayName != "" {
+			return fmt.Errorf("--new-secret-display-name cannot be combined with --retire-old-after-days > 0: deferred retirement recognizes a superseded credential by the marker rotationDisplayName embeds in its default name, which a custom name omits, so the old secret would never be retired")
+		}
+
+		return nil
+	}
+
+	return cmd
+}
+
+// buildRotationSinks resolves the --rotation-sink names into RotationSink
+// implementations.
+func buildRotationSinks(names []string, keyVaultURL, azdoOrgURL string, config Config) ([]RotationSink, error) {
+	var sinks []RotationSink
+	for _, name := range names {
+		switch name {
+		case rotationSinkStdoutJSON:
+			sinks = append(sinks, StdoutJSONSink{})
+		case rotationSinkKeyVault:
+			if keyVaultURL == "" {
+				return nil, fmt.Errorf("--keyvault-url is required for rotation-sink=keyvault")
+			}
+			cred, err := newCredential(config)
+			if err != nil {
+				return nil, fmt.Errorf("credential error: %v", err)
+			}
+			sink, err := NewKeyVaultSink(keyVaultURL, cred)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case rotationSinkAzureDevOps:
+			if azdoOrgURL == "" {
+				return nil, fmt.Errorf("--azdo-org-url is required for rotation-sink=azuredevops")
+			}
+			pat := os.Getenv("AZDO_PAT")
+			if pat == "" {
+				return nil, fmt.Errorf("AZDO_PAT environment variable is required for rotation-sink=azuredevops")
+			}
+			sinks = append(sinks, NewAzureDevOpsSink(azdoOrgURL, pat))
+		default:
+			return nil, fmt.Errorf("unknown rotation-sink %q: must be one of stdout-json, keyvault, azuredevops", name)
+		}
+	}
+	return sinks, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyVaultSecretName(t *testing.T) {
+	tests := []struct {
+		name        string
+		displayName string
+		want        string
+	}{
+		{"already valid", "my-app-secret", "my-app-secret"},
+		{"spaces become hyphens", "My App", "My-App"},
+		{"symbols become hyphens", "billing/prod (east)", "billing-prod--east"},
+		{"leading and trailing junk trimmed", "--My App--", "My-App"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keyVaultSecretName(tt.displayName); got != tt.want {
+				t.Errorf("keyVaultSecretName(%q) = %q, want %q", tt.displayName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotationDisplayNameRoundTrip(t *testing.T) {
+	name := rotationDisplayName("old-key-id")
+
+	mintedAt, replacesKeyID, ok := parseRotationMarker(name)
+	if !ok {
+		t.Fatalf("parseRotationMarker(%q) returned ok=false", name)
+	}
+	if replacesKeyID != "old-key-id" {
+		t.Errorf("replacesKeyID = %q, want %q", replacesKeyID, "old-key-id")
+	}
+	if time.Since(mintedAt) > time.Minute {
+		t.Errorf("mintedAt = %v, want close to now", mintedAt)
+	}
+}
+
+func TestParseRotationMarkerRejectsUnrelatedNames(t *testing.T) {
+	tests := []string{
+		"",
+		"my-custom-secret-name",
+		"auto-rotated-not-a-timestamp-replaces-abc",
+		"auto-rotated-12345",
+	}
+
+	for _, name := range tests {
+		if _, _, ok := parseRotationMarker(name); ok {
+			t.Errorf("parseRotationMarker(%q) returned ok=true, want false", name)
+		}
+	}
+}
+
+func TestFindReplacement(t *testing.T) {
+	cred := expiringCredential{
+		KeyID: "old-key-id",
+		Siblings: []passwordCredentialInfo{
+			{KeyID: "unrelated", DisplayName: "manually-added"},
+			{KeyID: "new-key-id", DisplayName: rotationDisplayName("old-key-id")},
+		},
+	}
+
+	mintedAt, ok := findReplacement(cred)
+	if !ok {
+		t.Fatalf("findReplacement() returned ok=false, want true")
+	}
+	if time.Since(mintedAt) > time.Minute {
+		t.Errorf("mintedAt = %v, want close to now", mintedAt)
+	}
+
+	if _, ok := findReplacement(expiringCredential{KeyID: "no-match"}); ok {
+		t.Errorf("findReplacement() returned ok=true for a credential with no siblings, want false")
+	}
+}
+
+func TestNewRotateCommandRejectsCustomNameWithRetireOldAfterDays(t *testing.T) {
+	config := &Config{}
+	cmd := newRotateCommand(config)
+
+	if err := cmd.Flags().Set("new-secret-display-name", "my-custom-name"); err != nil {
+		t.Fatalf("failed to set --new-secret-display-name: %v", err)
+	}
+	if err := cmd.Flags().Set("retire-old-after-days", "7"); err != nil {
+		t.Fatalf("failed to set --retire-old-after-days: %v", err)
+	}
+
+	if err := cmd.PreRunE(cmd, nil); err == nil {
+		t.Fatal("PreRunE returned nil error for --new-secret-display-name combined with --retire-old-after-days > 0, want an error")
+	}
+}
+
+func TestNewRotateCommandAllowsCustomNameWithoutRetirement(t *testing.T) {
+	config := &Config{}
+	cmd := newRotateCommand(config)
+
+	if err := cmd.Flags().Set("new-secret-display-name", "my-custom-name"); err != nil {
+		t.Fatalf("failed to set --new-secret-display-name: %v", err)
+	}
+
+	if err := cmd.PreRunE(cmd, nil); err != nil {
+		t.Fatalf("PreRunE returned error for --new-secret-display-name without --retire-old-after-days: %v", err)
+	}
+}